@@ -0,0 +1,643 @@
+// File: penguindex-go/commands.go
+package main
+
+import (
+	"strings"
+
+	"github.com/jendermine/penguindex-go/internal/agent"
+	"github.com/jendermine/penguindex-go/internal/cli"
+	"github.com/jendermine/penguindex-go/internal/commands"
+	"github.com/jendermine/penguindex-go/internal/config"
+	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"github.com/jendermine/penguindex-go/internal/utils"
+)
+
+// commandTree defines every command. Run functions check their arguments
+// before asking in for settings, the bundle or Drive.
+func commandTree(in *initializer) []*cli.Command {
+	return []*cli.Command{
+		uploadCommand(in),
+		downloadCommand(in),
+		deleteCommand(in),
+		restoreCommand(in),
+		trashCommand(in),
+		lsCommand(in),
+		pruneCommand(in),
+		drivesCommand(in),
+		accountsCommand(in),
+		foldersCommand(in),
+		agentCommand(in),
+		bundleCommand(in),
+		configCommand(in),
+	}
+}
+
+// values returns a fixed list of completion candidates.
+func values(candidates ...string) func() []string {
+	return func() []string { return candidates }
+}
+
+// noArgs rejects positional arguments.
+func noArgs(args []string) error {
+	if len(args) > 0 {
+		return cli.UsageErrorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+	return nil
+}
+
+func uploadCommand(in *initializer) *cli.Command {
+	cmd := &cli.Command{
+		Name:    "upload",
+		Summary: "Upload files, directories or glob matches to a Drive folder",
+		Args:    "[flags] (-file <file_dir_or_glob> | <file_dir_or_glob>...)",
+	}
+	fs := cmd.Flags()
+	filePath := fs.String("file", "", "Path, directory or glob pattern to upload; further paths may follow the flags")
+	folderID := fs.String("folder", "", "Folder alias, Google Drive folder ID or link (optional, uses default if not provided)")
+	drivePath := fs.String("path", "", "Folder path below -folder, e.g. \"Releases/2026/October\"; missing folders are created")
+	jobs := fs.Int("jobs", 1, "Number of files to upload in parallel")
+	deleteOnMismatch := fs.Bool("delete-on-mismatch", false, "Delete the uploaded copy if its MD5 checksum does not match the local file (never an existing file replaced by -if-exists overwrite)")
+	ifExists := fs.String("if-exists", "", "What to do when the folder already has a file with the same name: skip, overwrite, rename or error (default: upload a duplicate)")
+	compare := fs.String("compare", "none", "Skip files whose existing copy is identical by: none, size or md5 (requires -if-exists)")
+	cmd.FlagValues = map[string]func() []string{
+		"folder":    in.folderAliases,
+		"if-exists": values(commands.IfExistsSkip, commands.IfExistsOverwrite, commands.IfExistsRename, commands.IfExistsError),
+		"compare":   values(commands.CompareNone, commands.CompareSize, commands.CompareMD5),
+	}
+
+	cmd.Run = func(args []string) error {
+		var paths []string
+		if *filePath != "" {
+			paths = append(paths, *filePath)
+		}
+		paths = append(paths, args...)
+		if len(paths) == 0 {
+			return cli.UsageErrorf("-file or at least one path is required for upload")
+		}
+		if err := commands.ValidateIfExists(*ifExists, *compare); err != nil {
+			return cli.UsageErrorf("%v", err)
+		}
+		if *jobs < 1 {
+			return cli.UsageErrorf("-jobs must be at least 1")
+		}
+		appCfg := in.app()
+		target, err := commands.ResolveFolder(appCfg, *folderID)
+		if err != nil {
+			return cli.UsageErrorf("%v", err)
+		}
+		target.Path = *drivePath
+		deps := in.initialize(needsDrive | needsTelegram)
+		err = commands.HandleUpload(deps.driveService, deps.driveHTTPClient, appCfg, paths, target, commands.UploadOptions{
+			Jobs:             *jobs,
+			DeleteOnMismatch: *deleteOnMismatch,
+			IfExists:         *ifExists,
+			Compare:          *compare,
+		})
+		if err != nil {
+			return err
+		}
+		utils.Status(successColor("Upload command completed successfully."))
+		return nil
+	}
+	return cmd
+}
+
+func downloadCommand(in *initializer) *cli.Command {
+	cmd := &cli.Command{
+		Name:    "download",
+		Summary: "Download a file, or a folder recursively",
+		Args:    "[-o <path>] <fileID_or_link>",
+	}
+	outPath := cmd.Flags().String("o", "", "Destination file or directory (default: the Drive name in the current directory)")
+
+	cmd.Run = func(args []string) error {
+		if len(args) != 1 {
+			return cli.UsageErrorf("download takes exactly one file or folder ID or link")
+		}
+		if err := commands.HandleDownload(in.initialize(needsDrive).driveService, args[0], *outPath); err != nil {
+			return err
+		}
+		utils.Status(successColor("Download command completed successfully."))
+		return nil
+	}
+	return cmd
+}
+
+func deleteCommand(in *initializer) *cli.Command {
+	cmd := &cli.Command{
+		Name:    "delete",
+		Summary: "Move files to the trash, or delete them permanently",
+		Args:    "[-permanent] [-yes] (-id <fileID_or_link> | -from-file <path> | -query <drive_query> [-drive <id> | -all-drives] | - | <fileID_or_link>...)",
+		Help:    "\"-\" reads IDs or links from stdin, one per line. Bulk deletes ask for confirmation unless -yes is given. -query searches My Drive and items shared with the account unless -drive or -all-drives is given.",
+	}
+	fs := cmd.Flags()
+	fileIDOrLink := fs.String("id", "", "File ID or Google Drive link to delete")
+	permanent := fs.Bool("permanent", false, "Delete permanently instead of moving to trash (cannot be undone)")
+	fromFile := fs.String("from-file", "", "File with one ID or link per line to delete")
+	query := fs.String("query", "", "Delete every file matching this Drive search query, e.g. \"name contains 'tmp'\"")
+	assumeYes := fs.Bool("yes", false, "Do not ask for confirmation before a bulk delete")
+	driveID := fs.String("drive", "", "Search only this Shared Drive with -query")
+	allDrives := fs.Bool("all-drives", false, "Search every Shared Drive as well as My Drive with -query")
+
+	cmd.Run = func(args []string) error {
+		bulk := commands.BulkDeleteOptions{
+			FromFile:  *fromFile,
+			Query:     *query,
+			Scope:     gdrive.SearchScope{DriveID: *driveID, AllDrives: *allDrives},
+			Permanent: *permanent,
+			AssumeYes: *assumeYes,
+		}
+		switch {
+		case *driveID != "" && *allDrives:
+			return cli.UsageErrorf("-drive and -all-drives cannot be used together")
+		case (*driveID != "" || *allDrives) && *query == "":
+			return cli.UsageErrorf("-drive and -all-drives only apply to -query")
+		}
+		for _, arg := range args {
+			if arg == "-" {
+				bulk.FromStdin = true // "delete -" reads IDs or links from stdin
+				continue
+			}
+			bulk.Entries = append(bulk.Entries, arg)
+		}
+
+		var err error
+		switch {
+		case *fileIDOrLink != "" && len(bulk.Entries) == 0 && !bulk.FromStdin && bulk.FromFile == "" && bulk.Query == "":
+			err = commands.HandleDelete(in.initialize(needsDrive).driveService, in.app(), *fileIDOrLink, *permanent)
+		case *fileIDOrLink != "" || len(bulk.Entries) > 0 || bulk.FromStdin || bulk.FromFile != "" || bulk.Query != "":
+			if *fileIDOrLink != "" {
+				bulk.Entries = append([]string{*fileIDOrLink}, bulk.Entries...)
+			}
+			err = commands.HandleBulkDelete(in.initialize(needsDrive).driveService, bulk)
+		default:
+			return cli.UsageErrorf("-id, -from-file, -query, - or at least one ID is required for delete")
+		}
+		if err != nil {
+			return err
+		}
+		utils.Status(successColor("Delete command completed successfully."))
+		return nil
+	}
+	return cmd
+}
+
+func restoreCommand(in *initializer) *cli.Command {
+	return &cli.Command{
+		Name:    "restore",
+		Summary: "Take a file or folder out of the trash",
+		Args:    "<fileID_or_link>",
+		Run: func(args []string) error {
+			if len(args) != 1 {
+				return cli.UsageErrorf("restore takes exactly one file ID or link")
+			}
+			return commands.HandleRestore(in.initialize(needsDrive).driveService, args[0])
+		},
+	}
+}
+
+func trashCommand(in *initializer) *cli.Command {
+	// scopeFlags adds -drive and -all-drives to cmd and returns the scope they select.
+	scopeFlags := func(cmd *cli.Command) func() (gdrive.SearchScope, error) {
+		driveID := cmd.Flags().String("drive", "", "Use this Shared Drive's trash instead of the service account's own")
+		allDrives := cmd.Flags().Bool("all-drives", false, "Include the trash of every Shared Drive as well")
+		return func() (gdrive.SearchScope, error) {
+			if *driveID != "" && *allDrives {
+				return gdrive.SearchScope{}, cli.UsageErrorf("-drive and -all-drives cannot be used together")
+			}
+			return gdrive.SearchScope{DriveID: *driveID, AllDrives: *allDrives}, nil
+		}
+	}
+	list := func(scope func() (gdrive.SearchScope, error)) func(args []string) error {
+		return func(args []string) error {
+			if err := noArgs(args); err != nil {
+				return err
+			}
+			trashScope, err := scope()
+			if err != nil {
+				return err
+			}
+			return commands.HandleTrashList(in.initialize(needsDrive).driveService, trashScope, in.opts.json)
+		}
+	}
+
+	ls := &cli.Command{
+		Name:    "ls",
+		Summary: "List the trash, most recently trashed first",
+		Args:    "[-drive <id> | -all-drives]",
+		JSON:    true,
+	}
+	ls.Run = list(scopeFlags(ls))
+
+	empty := &cli.Command{
+		Name:    "empty",
+		Summary: "Permanently delete everything in the trash",
+		Args:    "[-yes] [-drive <id> | -all-drives]",
+	}
+	assumeYes := empty.Flags().Bool("yes", false, "Do not ask for confirmation")
+	emptyScope := scopeFlags(empty)
+	empty.Run = func(args []string) error {
+		if err := noArgs(args); err != nil {
+			return err
+		}
+		trashScope, err := emptyScope()
+		if err != nil {
+			return err
+		}
+		return commands.HandleTrashEmpty(in.initialize(needsDrive).driveService, trashScope, *assumeYes)
+	}
+
+	cmd := &cli.Command{
+		Name:        "trash",
+		Summary:     "List or empty the service account's trash (default: ls)",
+		Args:        "[ls | empty [-yes]] [-drive <id> | -all-drives]",
+		Help:        "Without -drive or -all-drives only the service account's own trash is used. Emptying a Shared Drive's trash needs the organizer role.",
+		JSON:        true,
+		Subcommands: []*cli.Command{ls, empty},
+	}
+	cmd.Run = list(scopeFlags(cmd))
+	return cmd
+}
+
+func lsCommand(in *initializer) *cli.Command {
+	cmd := &cli.Command{
+		Name:    "ls",
+		Summary: "List a folder's contents",
+		Args:    "[-sort name|size|modified] [-reverse] [-long] [-recursive] [alias_folderID_or_link]",
+		JSON:    true,
+	}
+	fs := cmd.Flags()
+	sortBy := fs.String("sort", commands.SortByName, "Sort by: name, size or modified (folders are always listed first)")
+	reverse := fs.Bool("reverse", false, "Reverse the sort order")
+	long := fs.Bool("long", false, "Also show MD5 checksum, created time and web link")
+	recursive := fs.Bool("recursive", false, "List subfolders recursively as a tree")
+	cmd.FlagValues = map[string]func() []string{
+		"sort": values(commands.SortByName, commands.SortBySize, commands.SortByModified),
+	}
+	cmd.CompleteArgs = func(args []string) []string {
+		if len(args) > 0 {
+			return nil
+		}
+		return in.folderAliases()
+	}
+
+	cmd.Run = func(args []string) error {
+		if len(args) > 1 {
+			return cli.UsageErrorf("ls takes at most one folder")
+		}
+		if err := commands.ValidateSortKey(*sortBy); err != nil {
+			return cli.UsageErrorf("%v", err)
+		}
+		var folderArg string
+		if len(args) == 1 {
+			folderArg = args[0]
+		}
+		target, err := commands.ResolveFolder(in.app(), folderArg)
+		if err != nil {
+			return cli.UsageErrorf("%v", err)
+		}
+		return commands.HandleList(in.initialize(needsDrive).driveService, target, commands.ListOptions{
+			SortBy:    *sortBy,
+			Reverse:   *reverse,
+			Long:      *long,
+			Recursive: *recursive,
+			JSON:      in.opts.json,
+		})
+	}
+	return cmd
+}
+
+func pruneCommand(in *initializer) *cli.Command {
+	cmd := &cli.Command{
+		Name:    "prune",
+		Summary: "Apply the configured retention rules",
+		Args:    "[-dry-run] [-permanent] [-yes] [-jobs N] [folder_alias_or_ID...]",
+		Help:    "Without folders, every folder with a retention rule in the active profile is pruned.",
+	}
+	fs := cmd.Flags()
+	dryRun := fs.Bool("dry-run", false, "Only print what would be pruned")
+	permanent := fs.Bool("permanent", false, "Delete permanently instead of moving to trash (cannot be undone)")
+	assumeYes := fs.Bool("yes", false, "Do not ask for confirmation")
+	jobs := fs.Int("jobs", 4, "Number of files to delete in parallel")
+	cmd.CompleteArgs = func([]string) []string { return in.folderAliases() }
+
+	cmd.Run = func(args []string) error {
+		if *jobs < 1 {
+			return cli.UsageErrorf("-jobs must be at least 1")
+		}
+		needs := needsDrive | needsTelegram
+		if *dryRun {
+			needs = needsDrive // A dry run sends no notification
+		}
+		return commands.HandlePrune(in.initialize(needs).driveService, in.app(), commands.PruneOptions{
+			DryRun:    *dryRun,
+			Permanent: *permanent,
+			AssumeYes: *assumeYes,
+			Jobs:      *jobs,
+			Folders:   args,
+		})
+	}
+	return cmd
+}
+
+func drivesCommand(in *initializer) *cli.Command {
+	list := func(args []string) error {
+		if err := noArgs(args); err != nil {
+			return err
+		}
+		return commands.HandleDrivesList(in.initialize(needsDrive).driveService, in.opts.json)
+	}
+	return &cli.Command{
+		Name:    "drives",
+		Summary: "List the Shared Drives the service account can access (default: ls)",
+		Args:    "[ls]",
+		JSON:    true,
+		Run:     list,
+		Subcommands: []*cli.Command{
+			{Name: "ls", Summary: "List Shared Drives with their IDs and access", JSON: true, Run: list},
+		},
+	}
+}
+
+func accountsCommand(in *initializer) *cli.Command {
+	list := func(args []string) error {
+		if err := noArgs(args); err != nil {
+			return err
+		}
+		return commands.HandleAccountsList(in.initialize(needsDrive).driveHTTPClient, in.opts.json)
+	}
+	return &cli.Command{
+		Name:    "accounts",
+		Summary: "Show the bundle's service accounts and today's upload quota (default: ls)",
+		Args:    "[ls]",
+		JSON:    true,
+		Run:     list,
+		Subcommands: []*cli.Command{
+			{Name: "ls", Summary: "List service accounts with today's uploads", JSON: true, Run: list},
+		},
+	}
+}
+
+func foldersCommand(in *initializer) *cli.Command {
+	list := func(args []string) error {
+		if err := noArgs(args); err != nil {
+			return err
+		}
+		return commands.HandleFoldersList(in.initialize(needsDrive).driveService, in.load())
+	}
+	return &cli.Command{
+		Name:    "folders",
+		Summary: "Manage folder aliases of the active profile (default: ls)",
+		Args:    "[ls | add <alias> <folderID_or_link> | rm <alias>]",
+		Run:     list,
+		Subcommands: []*cli.Command{
+			{Name: "ls", Summary: "List folder aliases and check their folders", Run: list},
+			{
+				Name:    "add",
+				Summary: "Add or change a folder alias",
+				Args:    "<alias> <folderID_or_link>",
+				Run: func(args []string) error {
+					if len(args) != 2 {
+						return cli.UsageErrorf("folders add takes an alias and a folder ID or link")
+					}
+					return commands.HandleFoldersAdd(in.initialize(needsDrive).driveService, in.load(), args[0], args[1])
+				},
+			},
+			{
+				Name:    "rm",
+				Summary: "Remove a folder alias",
+				Args:    "<alias>",
+				Run: func(args []string) error {
+					if len(args) != 1 {
+						return cli.UsageErrorf("folders rm takes exactly one alias")
+					}
+					return commands.HandleFoldersRemove(in.load(), args[0])
+				},
+				CompleteArgs: func(args []string) []string {
+					if len(args) > 0 {
+						return nil
+					}
+					return in.folderAliases()
+				},
+			},
+		},
+	}
+}
+
+func agentCommand(in *initializer) *cli.Command {
+	start := &cli.Command{
+		Name:    "start",
+		Summary: "Run the agent in the foreground",
+		Args:    "[-idle <duration>]",
+	}
+	idle := start.Flags().Duration("idle", agent.DEFAULT_IDLE_TIMEOUT, "Forget the cached bundle after this long without use")
+	start.Run = func(args []string) error {
+		if err := noArgs(args); err != nil {
+			return err
+		}
+		return commands.HandleAgentStart(*idle)
+	}
+
+	return &cli.Command{
+		Name:    "agent",
+		Summary: "Cache the decrypted bundle in memory between commands (default: start)",
+		Args:    "[start [-idle <duration>] | status | lock | stop]",
+		Run:     start.Run,
+		Subcommands: []*cli.Command{
+			start,
+			{
+				Name:    "status",
+				Summary: "Show whether an agent is running and what it holds",
+				JSON:    true,
+				Run: func(args []string) error {
+					if err := noArgs(args); err != nil {
+						return err
+					}
+					return commands.HandleAgentStatus(in.opts.json)
+				},
+			},
+			{
+				Name:    "lock",
+				Summary: "Forget the cached bundle without stopping the agent",
+				Run: func(args []string) error {
+					if err := noArgs(args); err != nil {
+						return err
+					}
+					return commands.HandleAgentLock()
+				},
+			},
+			{
+				Name:    "stop",
+				Summary: "Wipe the cached bundle and stop the agent",
+				Run: func(args []string) error {
+					if err := noArgs(args); err != nil {
+						return err
+					}
+					return commands.HandleAgentStop()
+				},
+			},
+		},
+	}
+}
+
+// bundleWriteFlags registers the flags of the bundle commands that write a
+// new bundle and returns a function that collects them.
+func bundleWriteFlags(cmd *cli.Command) func() (commands.BundleWriteOptions, error) {
+	fs := cmd.Flags()
+	outPath := fs.String("o", "", "Where to write the encrypted bundle (\"-\" for stdout)")
+	newPINFile := fs.String("new-pin-file", "", "Read the new PIN from this file instead of $PENGUINDEX_NEW_PIN or a prompt")
+	kdfName := fs.String("kdf", "argon2id", "Key derivation for the new bundle: argon2id or pbkdf2")
+	signKeyFile := fs.String("sign-key", "", "Sign the output with this private key from 'bundle keygen'")
+	signURL := fs.String("url", "", "With -sign-key, the URL the bundle will be published at; it is refused anywhere else")
+	cmd.FlagValues = map[string]func() []string{"kdf": values("argon2id", "pbkdf2")}
+
+	return func() (commands.BundleWriteOptions, error) {
+		kdf, err := config.KDFByName(*kdfName)
+		if err != nil {
+			return commands.BundleWriteOptions{}, cli.UsageErrorf("%v", err)
+		}
+		if *signURL != "" && *signKeyFile == "" {
+			return commands.BundleWriteOptions{}, cli.UsageErrorf("-url only applies with -sign-key")
+		}
+		return commands.BundleWriteOptions{OutPath: *outPath, NewPINFile: *newPINFile, KDF: kdf, SignKeyFile: *signKeyFile, SignURL: *signURL}, nil
+	}
+}
+
+func bundleCommand(in *initializer) *cli.Command {
+	// decrypt, rekey and sign default to the profile's bundle URL.
+	source := func(args []string) (string, error) {
+		switch len(args) {
+		case 0:
+			return in.load().BundleURL.Value, nil
+		case 1:
+			return args[0], nil
+		}
+		return "", cli.UsageErrorf("expected at most one bundle file or URL")
+	}
+
+	encrypt := &cli.Command{
+		Name:    "encrypt",
+		Summary: "Encrypt service account keys and the bot token into a new bundle",
+		Args:    "-o <out.json|-> [-token-file <path>] [-new-pin-file <path>] [-kdf argon2id|pbkdf2] [-sign-key <key> [-url <publish_URL>]] <service_account.json>...",
+	}
+	encryptOpts := bundleWriteFlags(encrypt)
+	tokenFile := encrypt.Flags().String("token-file", "", "File containing the Telegram bot token (default: prompt)")
+	encrypt.Run = func(args []string) error {
+		writeOpts, err := encryptOpts()
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 || writeOpts.OutPath == "" {
+			return cli.UsageErrorf("bundle encrypt needs -o and at least one service account file")
+		}
+		return commands.HandleBundleEncrypt(commands.BundleEncryptOptions{
+			BundleWriteOptions:  writeOpts,
+			ServiceAccountFiles: args,
+			TokenFile:           *tokenFile,
+		})
+	}
+
+	rekey := &cli.Command{
+		Name:    "rekey",
+		Summary: "Re-encrypt a bundle with a new PIN or KDF",
+		Args:    "-o <out.json|-> [-new-pin-file <path>] [-kdf argon2id|pbkdf2] [-sign-key <key> [-url <publish_URL>]] [<bundle.json_or_URL>]",
+	}
+	rekeyOpts := bundleWriteFlags(rekey)
+	rekey.Run = func(args []string) error {
+		writeOpts, err := rekeyOpts()
+		if err != nil {
+			return err
+		}
+		if writeOpts.OutPath == "" {
+			return cli.UsageErrorf("bundle rekey needs -o; it never replaces the source bundle")
+		}
+		src, err := source(args)
+		if err != nil {
+			return err
+		}
+		return commands.HandleBundleRekey(src, in.network(), in.pinOptions(), writeOpts)
+	}
+
+	keygen := &cli.Command{
+		Name:    "keygen",
+		Summary: "Generate an Ed25519 key for signing bundles and chat IDs",
+		Args:    "-o <signing.key>",
+	}
+	keyPath := keygen.Flags().String("o", "", "Where to write the private key")
+	keygen.Run = func(args []string) error {
+		if len(args) > 0 || *keyPath == "" {
+			return cli.UsageErrorf("bundle keygen takes only -o <signing.key>")
+		}
+		return commands.HandleBundleKeygen(*keyPath)
+	}
+
+	sign := &cli.Command{
+		Name:    "sign",
+		Summary: "Sign an existing bundle or a Telegram chat ID",
+		Args:    "-sign-key <key> [-url <publish_URL>] ([-o <out.json|->] [<bundle.json_or_URL>] | -chat-id <id> -o <chat_id.json|->)",
+	}
+	signFS := sign.Flags()
+	signKeyFile := signFS.String("sign-key", "", "Private key from 'bundle keygen'")
+	chatID := signFS.String("chat-id", "", "Sign this Telegram chat ID instead of a bundle")
+	signOut := signFS.String("o", "", "Where to write the signed document (\"-\" for stdout)")
+	signURL := signFS.String("url", "", "The URL the document will be published at; it is refused anywhere else")
+	sign.Run = func(args []string) error {
+		if *signKeyFile == "" {
+			return cli.UsageErrorf("-sign-key is required")
+		}
+		if *chatID != "" {
+			if len(args) > 0 || *signOut == "" {
+				return cli.UsageErrorf("-chat-id needs -o and no bundle argument")
+			}
+			return commands.HandleBundleSignChatID(*chatID, *signKeyFile, *signOut, *signURL)
+		}
+		src, err := source(args)
+		if err != nil {
+			return err
+		}
+		return commands.HandleBundleSign(src, *signKeyFile, *signOut, *signURL)
+	}
+
+	return &cli.Command{
+		Name:    "bundle",
+		Summary: "Create, inspect, re-encrypt and sign encrypted bundles",
+		Subcommands: []*cli.Command{
+			encrypt,
+			{
+				Name:    "decrypt",
+				Summary: "Decrypt a bundle to check the PIN and show its non-secret metadata",
+				Args:    "[<bundle.json_or_URL>]",
+				Run: func(args []string) error {
+					src, err := source(args)
+					if err != nil {
+						return err
+					}
+					return commands.HandleBundleDecrypt(src, in.network(), in.pinOptions())
+				},
+			},
+			rekey,
+			keygen,
+			sign,
+		},
+	}
+}
+
+func configCommand(in *initializer) *cli.Command {
+	return &cli.Command{
+		Name:    "config",
+		Summary: "Inspect the local configuration",
+		Subcommands: []*cli.Command{
+			{
+				Name:    "show",
+				Summary: "Print the resolved settings and where each came from",
+				JSON:    true,
+				Run: func(args []string) error {
+					if err := noArgs(args); err != nil {
+						return err
+					}
+					return commands.HandleConfigShow(in.load(), in.opts.json)
+				},
+			},
+		},
+	}
+}
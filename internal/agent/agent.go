@@ -0,0 +1,308 @@
+// File: penguindex-go/internal/agent/agent.go
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/config"
+	"github.com/jendermine/penguindex-go/internal/utils"
+)
+
+// SOCKET_FILE is the agent's socket in the user cache directory.
+const SOCKET_FILE = "agent.sock"
+
+// ENV_AGENT_SOCK overrides the socket path, like SSH_AUTH_SOCK.
+const ENV_AGENT_SOCK = "PENGUINDEX_AGENT_SOCK"
+
+// DEFAULT_IDLE_TIMEOUT is how long an unused bundle stays cached.
+const DEFAULT_IDLE_TIMEOUT = 30 * time.Minute
+
+// connTimeout bounds a single request/response exchange.
+const connTimeout = 5 * time.Second
+
+// Operations understood by the agent.
+const (
+	opGet    = "get"
+	opAdd    = "add"
+	opLock   = "lock"
+	opStop   = "stop"
+	opStatus = "status"
+)
+
+// request is one client message; every connection carries exactly one.
+type request struct {
+	Op        string          `json:"op"`
+	BundleURL string          `json:"bundle_url,omitempty"`
+	Bundle    json.RawMessage `json:"bundle,omitempty"`
+}
+
+// response answers a request. Bundle is only set for a successful get.
+type response struct {
+	Error  string          `json:"error,omitempty"`
+	Bundle json.RawMessage `json:"bundle,omitempty"`
+	Status *Status         `json:"status,omitempty"`
+}
+
+// Status describes a running agent.
+type Status struct {
+	PID       int       `json:"pid"`
+	Locked    bool      `json:"locked"`               // No bundle is cached
+	BundleURL string    `json:"bundle_url,omitempty"` // Where the cached bundle came from
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // When the idle timeout locks the agent
+	Mlocked   bool      `json:"mlocked"`              // Whether the bundle is excluded from swap
+}
+
+// SocketPath returns $PENGUINDEX_AGENT_SOCK or <user cache dir>/penguindex/agent.sock.
+// The cache directory is created user-only, which keeps other users away
+// from the socket.
+func SocketPath() (string, error) {
+	if path := os.Getenv(ENV_AGENT_SOCK); path != "" {
+		return path, nil
+	}
+	dir, err := utils.AppCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, SOCKET_FILE), nil
+}
+
+// server holds at most one decrypted bundle in locked memory.
+type server struct {
+	mu        sync.Mutex
+	bundleURL string
+	secret    *lockedBuffer // JSON-encoded DecryptedBundle; nil while locked
+	idle      time.Duration
+	timer     *time.Timer
+	expiresAt time.Time
+	listener  net.Listener
+	stopping  bool
+}
+
+// Serve runs the agent in the foreground until it is stopped with Stop or a
+// signal. It starts locked: the first command that decrypts the bundle adds
+// it, and it is wiped again after idle without use.
+func Serve(idle time.Duration) error {
+	path, err := SocketPath()
+	if err != nil {
+		return err
+	}
+	if Running() {
+		return fmt.Errorf("an agent is already listening on %s", path)
+	}
+	_ = os.Remove(path) // Stale socket from an agent that was killed
+	listener, err := listenSocket(path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+
+	s := &server{idle: idle, listener: listener}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		if _, ok := <-signals; ok {
+			s.stop()
+		}
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			stopping := s.stopping
+			s.mu.Unlock()
+			if stopping {
+				return nil
+			}
+			return fmt.Errorf("agent socket failed: %w", err)
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle serves one request on conn. Connections from other users are
+// refused before the request is read.
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(connTimeout))
+
+	var req request
+	var resp response
+	if err := checkPeer(conn); err != nil {
+		_ = json.NewEncoder(conn).Encode(response{Error: err.Error()})
+		return
+	}
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		resp.Error = fmt.Sprintf("bad request: %v", err)
+	} else {
+		resp = s.dispatch(req)
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+	if req.Op == opStop && resp.Error == "" {
+		s.stop()
+	}
+}
+
+func (s *server) dispatch(req request) response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Op {
+	case opGet:
+		if s.secret == nil {
+			return response{Error: "agent is locked"}
+		}
+		if req.BundleURL != s.bundleURL {
+			return response{Error: fmt.Sprintf("agent holds the bundle from %s", s.bundleURL)}
+		}
+		s.touch()
+		return response{Bundle: append(json.RawMessage(nil), s.secret.Bytes()...)}
+	case opAdd:
+		if len(req.Bundle) == 0 || req.BundleURL == "" {
+			return response{Error: "add needs a bundle and its URL"}
+		}
+		s.wipe()
+		s.secret = newLockedBuffer(req.Bundle)
+		s.bundleURL = req.BundleURL
+		s.touch()
+		return response{}
+	case opLock, opStop:
+		s.wipe()
+		return response{}
+	case opStatus:
+		return response{Status: &Status{
+			PID:       os.Getpid(),
+			Locked:    s.secret == nil,
+			BundleURL: s.bundleURL,
+			ExpiresAt: s.expiresAt,
+			Mlocked:   s.secret != nil && s.secret.locked,
+		}}
+	default:
+		return response{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+	}
+}
+
+// touch restarts the idle timer. Callers hold s.mu.
+func (s *server) touch() {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.expiresAt = time.Now().Add(s.idle)
+	s.timer = time.AfterFunc(s.idle, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if time.Now().Before(s.expiresAt) {
+			return // Touched again while this timer fired
+		}
+		s.wipe()
+	})
+}
+
+// wipe zeroes and forgets the cached bundle. Callers hold s.mu.
+func (s *server) wipe() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.secret != nil {
+		s.secret.Wipe()
+		s.secret = nil
+	}
+	s.bundleURL = ""
+	s.expiresAt = time.Time{}
+}
+
+// stop wipes the bundle and closes the listener, which also removes the socket.
+func (s *server) stop() {
+	s.mu.Lock()
+	s.wipe()
+	s.stopping = true
+	s.mu.Unlock()
+	s.listener.Close()
+}
+
+// call sends req to the running agent and returns its response.
+func call(req request) (*response, error) {
+	path, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("unix", path, connTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("no agent running on %s: %w", path, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(connTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request to agent: %w", err)
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read agent response: %w", err)
+	}
+	if resp.Error != "" {
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// Running reports whether an agent answers on the socket.
+func Running() bool {
+	_, err := call(request{Op: opStatus})
+	return err == nil
+}
+
+// Lookup returns the bundle a running agent has cached for bundleURL, or nil
+// if no agent is running, it is locked, or it holds a different bundle.
+func Lookup(bundleURL string) *config.DecryptedBundle {
+	resp, err := call(request{Op: opGet, BundleURL: bundleURL})
+	if err != nil {
+		return nil
+	}
+	var bundle config.DecryptedBundle
+	if err := json.Unmarshal(resp.Bundle, &bundle); err != nil {
+		return nil
+	}
+	return &bundle
+}
+
+// Add caches bundle in the running agent, replacing whatever it held.
+func Add(bundleURL string, bundle *config.DecryptedBundle) error {
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	_, err = call(request{Op: opAdd, BundleURL: bundleURL, Bundle: data})
+	return err
+}
+
+// Lock makes the running agent forget its bundle.
+func Lock() error {
+	_, err := call(request{Op: opLock})
+	return err
+}
+
+// Stop makes the running agent forget its bundle and exit.
+func Stop() error {
+	_, err := call(request{Op: opStop})
+	return err
+}
+
+// GetStatus asks the running agent for its state.
+func GetStatus() (*Status, error) {
+	resp, err := call(request{Op: opStatus})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
@@ -0,0 +1,125 @@
+// File: penguindex-go/internal/agent/agent_test.go
+
+//go:build unix
+
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/config"
+)
+
+const testBundleURL = "https://example.com/bundle.json"
+
+// startAgent serves an agent with the given idle timeout on a socket in a
+// fresh directory and stops it when the test ends. It returns the socket path.
+func startAgent(t *testing.T, idle time.Duration) string {
+	t.Helper()
+	// t.TempDir can exceed the length limit of a socket path.
+	dir, err := os.MkdirTemp("", "pgx-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, SOCKET_FILE)
+	t.Setenv(ENV_AGENT_SOCK, path)
+
+	done := make(chan error, 1)
+	go func() { done <- Serve(idle) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for !Running() {
+		if time.Now().After(deadline) {
+			t.Fatal("agent did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Cleanup(func() {
+		if Running() {
+			_ = Stop()
+		}
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return path
+}
+
+func TestAgentCommands(t *testing.T) {
+	startAgent(t, time.Minute)
+	bundle := &config.DecryptedBundle{ServiceAccountJSONString: `{"type":"service_account"}`, TelegramBotToken: "123:abc"}
+
+	status, err := GetStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Locked || status.PID != os.Getpid() {
+		t.Errorf("initial status = %+v, want locked with pid %d", status, os.Getpid())
+	}
+	if got := Lookup(testBundleURL); got != nil {
+		t.Errorf("Lookup on a locked agent = %+v, want nil", got)
+	}
+
+	if err := Add(testBundleURL, bundle); err != nil {
+		t.Fatal(err)
+	}
+	if got := Lookup(testBundleURL); !reflect.DeepEqual(got, bundle) {
+		t.Errorf("Lookup after Add = %+v, want %+v", got, bundle)
+	}
+	if got := Lookup("https://example.com/other.json"); got != nil {
+		t.Errorf("Lookup for another URL = %+v, want nil", got)
+	}
+	status, err = GetStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Locked || status.BundleURL != testBundleURL || status.ExpiresAt.IsZero() {
+		t.Errorf("status after Add = %+v, want unlocked for %s with an expiry", status, testBundleURL)
+	}
+
+	if err := Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if got := Lookup(testBundleURL); got != nil {
+		t.Errorf("Lookup after Lock = %+v, want nil", got)
+	}
+	if status, err := GetStatus(); err != nil || !status.Locked || status.BundleURL != "" {
+		t.Errorf("status after Lock = %+v, %v; want locked", status, err)
+	}
+
+	if err := Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if Running() {
+		t.Error("agent still answers after Stop")
+	}
+}
+
+func TestAgentIdleTimeout(t *testing.T) {
+	startAgent(t, 100*time.Millisecond)
+	if err := Add(testBundleURL, &config.DecryptedBundle{TelegramBotToken: "123:abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if Lookup(testBundleURL) == nil {
+		t.Fatal("Lookup right after Add returned nil")
+	}
+	time.Sleep(300 * time.Millisecond)
+	if got := Lookup(testBundleURL); got != nil {
+		t.Errorf("Lookup after the idle timeout = %+v, want nil", got)
+	}
+}
+
+func TestAgentSocketIsUserOnly(t *testing.T) {
+	path := startAgent(t, time.Minute)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %v, want a socket with mode 0600", info.Mode())
+	}
+}
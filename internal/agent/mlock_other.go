@@ -0,0 +1,27 @@
+// File: penguindex-go/internal/agent/mlock_other.go
+
+//go:build !unix
+
+package agent
+
+// lockedBuffer holds a secret on the Go heap; this platform offers no mlock,
+// so the secret is only zeroed on Wipe.
+type lockedBuffer struct {
+	data   []byte
+	locked bool
+}
+
+func newLockedBuffer(secret []byte) *lockedBuffer {
+	return &lockedBuffer{data: append([]byte(nil), secret...)}
+}
+
+// Bytes returns the secret; it is only valid until Wipe.
+func (b *lockedBuffer) Bytes() []byte {
+	return b.data
+}
+
+// Wipe zeroes the secret.
+func (b *lockedBuffer) Wipe() {
+	clear(b.data)
+	b.data = nil
+}
@@ -0,0 +1,45 @@
+// File: penguindex-go/internal/agent/mlock_unix.go
+
+//go:build unix
+
+package agent
+
+import "golang.org/x/sys/unix"
+
+// lockedBuffer holds a secret in anonymous memory outside the Go heap,
+// mlocked so it is never written to swap. If mlock is not permitted (e.g.
+// RLIMIT_MEMLOCK is exhausted) the memory is still private but swappable.
+type lockedBuffer struct {
+	data   []byte
+	mapped bool
+	locked bool
+}
+
+func newLockedBuffer(secret []byte) *lockedBuffer {
+	data, err := unix.Mmap(-1, 0, max(len(secret), 1), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return &lockedBuffer{data: append([]byte(nil), secret...)}
+	}
+	b := &lockedBuffer{data: data[:len(secret)], mapped: true}
+	b.locked = unix.Mlock(data) == nil
+	copy(b.data, secret)
+	return b
+}
+
+// Bytes returns the secret; it is only valid until Wipe.
+func (b *lockedBuffer) Bytes() []byte {
+	return b.data
+}
+
+// Wipe zeroes the secret and releases its memory.
+func (b *lockedBuffer) Wipe() {
+	clear(b.data)
+	if b.mapped {
+		full := b.data[:cap(b.data)]
+		if b.locked {
+			_ = unix.Munlock(full)
+		}
+		_ = unix.Munmap(full)
+	}
+	b.data = nil
+}
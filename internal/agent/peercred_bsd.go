@@ -0,0 +1,26 @@
+// File: penguindex-go/internal/agent/peercred_bsd.go
+
+//go:build darwin || freebsd
+
+package agent
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerUID returns the user ID of the process on the other end of conn
+// (LOCAL_PEERCRED, which getpeereid is built on).
+func peerUID(conn *net.UnixConn) (int, error) {
+	var uid int
+	err := socketFD(conn, func(fd int) error {
+		cred, err := unix.GetsockoptXucred(fd, unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+		if err != nil {
+			return err
+		}
+		uid = int(cred.Uid)
+		return nil
+	})
+	return uid, err
+}
@@ -0,0 +1,25 @@
+// File: penguindex-go/internal/agent/peercred_linux.go
+
+//go:build linux
+
+package agent
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerUID returns the user ID of the process on the other end of conn (SO_PEERCRED).
+func peerUID(conn *net.UnixConn) (int, error) {
+	var uid int
+	err := socketFD(conn, func(fd int) error {
+		cred, err := unix.GetsockoptUcred(fd, unix.SOL_SOCKET, unix.SO_PEERCRED)
+		if err != nil {
+			return err
+		}
+		uid = int(cred.Uid)
+		return nil
+	})
+	return uid, err
+}
@@ -0,0 +1,16 @@
+// File: penguindex-go/internal/agent/peercred_other.go
+
+//go:build unix && !linux && !darwin && !freebsd
+
+package agent
+
+import (
+	"errors"
+	"net"
+)
+
+// peerUID is not available on this platform; the socket's permissions are
+// the only protection.
+func peerUID(conn *net.UnixConn) (int, error) {
+	return 0, errors.ErrUnsupported
+}
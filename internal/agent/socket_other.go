@@ -0,0 +1,18 @@
+// File: penguindex-go/internal/agent/socket_other.go
+
+//go:build !unix
+
+package agent
+
+import "net"
+
+// listenSocket creates the agent socket. This platform has no umask or
+// peer credentials, so the socket relies on the user-only cache directory.
+func listenSocket(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
+
+// checkPeer accepts every connection; see listenSocket.
+func checkPeer(conn net.Conn) error {
+	return nil
+}
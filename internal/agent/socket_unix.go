@@ -0,0 +1,64 @@
+// File: penguindex-go/internal/agent/socket_unix.go
+
+//go:build unix
+
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenSocket creates the agent socket user-only from the start. The umask
+// is tightened around net.Listen, so there is no window in which another
+// user could connect before the chmod; the chmod only guards against a
+// filesystem that ignores the umask.
+func listenSocket(path string) (net.Listener, error) {
+	oldMask := syscall.Umask(0o077)
+	listener, err := net.Listen("unix", path)
+	syscall.Umask(oldMask)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict permissions of %s: %w", path, err)
+	}
+	return listener, nil
+}
+
+// checkPeer refuses connections from processes of other users. Where the
+// platform cannot report the peer, the socket's permissions alone apply.
+func checkPeer(conn net.Conn) error {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("not a unix socket connection")
+	}
+	uid, err := peerUID(unixConn)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot identify peer: %w", err)
+	}
+	if uid != os.Getuid() {
+		return fmt.Errorf("connection from user %d refused", uid)
+	}
+	return nil
+}
+
+// socketFD runs fn with the file descriptor behind conn.
+func socketFD(conn *net.UnixConn, fn func(fd int) error) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var fnErr error
+	if err := raw.Control(func(fd uintptr) { fnErr = fn(int(fd)) }); err != nil {
+		return err
+	}
+	return fnErr
+}
@@ -3,25 +3,48 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
+	"github.com/jendermine/penguindex-go/internal/httpclient"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
 )
 
 // GetAuthenticatedClient creates an HTTP client authenticated with Google Cloud
-// using the provided service account JSON string.
-func GetAuthenticatedClient(serviceAccountJSONString string) (*http.Client, error) {
-	ctx := context.Background()
+// using the provided service account JSON strings. The client's transport is
+// a *Pool (see PoolFromClient): with more than one account, uploads move on
+// to the next account when the current one runs out of upload quota.
+func GetAuthenticatedClient(serviceAccountJSONStrings ...string) (*http.Client, error) {
+	pool, err := NewPool(serviceAccountJSONStrings)
+	if err != nil {
+		return nil, err
+	}
+	return pool.Client(), nil
+}
+
+// newAccountTransport authenticates one service account and returns its
+// transport together with the account's email address.
+func newAccountTransport(serviceAccountJSONString string) (http.RoundTripper, string, error) {
+	// Token requests and the Drive calls on top of them both go through the
+	// shared client, so they honour the configured proxy, CA and timeouts.
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpclient.Client())
 	creds, err := google.CredentialsFromJSON(ctx, []byte(serviceAccountJSONString), drive.DriveScope)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create credentials from service account JSON: %w", err)
+		return nil, "", fmt.Errorf("failed to create credentials from service account JSON: %w", err)
+	}
+	var key struct {
+		ClientEmail string `json:"client_email"`
+	}
+	if err := json.Unmarshal(creds.JSON, &key); err != nil || key.ClientEmail == "" {
+		return nil, "", fmt.Errorf("service account JSON has no client_email")
 	}
 
 	client := oauth2.NewClient(ctx, creds.TokenSource)
-	return client, nil
+	return client.Transport, key.ClientEmail, nil
 }
 
 // NewDriveService creates a new Google Drive service client using an authenticated HTTP client.
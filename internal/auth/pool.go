@@ -0,0 +1,291 @@
+// File: penguindex-go/internal/auth/pool.go
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/utils"
+)
+
+// USAGE_FILE records per-account upload usage in the user cache directory.
+const USAGE_FILE = "service_accounts.json"
+
+// usageRetentionDays is how many days of usage history are kept.
+const usageRetentionDays = 7
+
+// AccountUsage is one service account's upload usage on one day.
+type AccountUsage struct {
+	UploadedBytes int64      `json:"uploaded_bytes"`
+	ExhaustedAt   *time.Time `json:"exhausted_at,omitempty"` // When the account hit its upload quota
+}
+
+// AccountStatus describes a pool account for display.
+type AccountStatus struct {
+	Email   string       `json:"email"`
+	Current bool         `json:"current"` // The account requests are sent as
+	Today   AccountUsage `json:"today"`
+}
+
+// poolAccount is one authenticated service account of a Pool.
+type poolAccount struct {
+	email     string
+	transport http.RoundTripper
+}
+
+// Pool is an http.RoundTripper that sends every request as one service
+// account out of several. Uploads switch to the next account with
+// RotateAccount when the current one runs out of its daily upload quota.
+// Bytes uploaded per account and day are recorded in USAGE_FILE.
+type Pool struct {
+	mu       sync.Mutex
+	accounts []*poolAccount
+	current  int
+	sessions map[string]int                      // Resumable upload_id -> account that started it
+	usage    map[string]map[string]*AccountUsage // Day -> email -> usage
+}
+
+// NewPool authenticates every service account JSON string. The first
+// account not already marked exhausted today becomes the current one.
+func NewPool(serviceAccountJSONStrings []string) (*Pool, error) {
+	if len(serviceAccountJSONStrings) == 0 {
+		return nil, fmt.Errorf("no service account credentials in bundle")
+	}
+	var accounts []*poolAccount
+	for i, serviceAccountJSONString := range serviceAccountJSONStrings {
+		transport, email, err := newAccountTransport(serviceAccountJSONString)
+		if err != nil {
+			return nil, fmt.Errorf("service account %d: %w", i+1, err)
+		}
+		accounts = append(accounts, &poolAccount{email: email, transport: transport})
+	}
+	return newPool(accounts), nil
+}
+
+// newPool builds a pool from authenticated accounts and loads their usage.
+func newPool(accounts []*poolAccount) *Pool {
+	pool := &Pool{accounts: accounts, sessions: map[string]int{}}
+	usage, err := loadUsage()
+	if err != nil {
+		// Usage tracking is informational; the pool works without it.
+		usage = map[string]map[string]*AccountUsage{}
+	}
+	pool.usage = usage
+	for i := range pool.accounts {
+		if !pool.exhaustedToday(i) {
+			pool.current = i
+			break
+		}
+	}
+	return pool
+}
+
+// Client returns an HTTP client that sends its requests through the pool.
+func (p *Pool) Client() *http.Client {
+	return &http.Client{Transport: p}
+}
+
+// RoundTrip implements http.RoundTripper. Requests to a resumable upload
+// session go out as the account that started it; everything else uses the
+// current account.
+func (p *Pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	uploadID := req.URL.Query().Get("upload_id")
+	p.mu.Lock()
+	index, ok := p.sessions[uploadID]
+	if !ok {
+		index = p.current
+	}
+	account := p.accounts[index]
+	p.mu.Unlock()
+
+	resp, err := account.transport.RoundTrip(req)
+	if err != nil || !strings.HasPrefix(req.URL.Path, "/upload/") {
+		return resp, err
+	}
+
+	switch {
+	case uploadID == "" && resp.StatusCode == http.StatusOK:
+		// A new resumable session; its chunks must use the same account.
+		if location, err := resp.Location(); err == nil {
+			if id := location.Query().Get("upload_id"); id != "" {
+				p.mu.Lock()
+				p.sessions[id] = index
+				p.mu.Unlock()
+			}
+		}
+	case uploadID != "" && req.ContentLength > 0:
+		if n := committedChunkBytes(req, resp); n > 0 {
+			p.recordUpload(index, n)
+		}
+	}
+	return resp, nil
+}
+
+// committedChunkBytes returns how many bytes of an upload chunk Drive kept.
+// A finished upload (200/201) kept the whole chunk; a 308 kept only up to
+// the end of its Range header, which may stop short of the chunk's end.
+func committedChunkBytes(req *http.Request, resp *http.Response) int64 {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return req.ContentLength
+	case http.StatusPermanentRedirect:
+		var start, end, size int64
+		if _, err := fmt.Sscanf(req.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &size); err != nil {
+			return 0
+		}
+		var committed int64
+		if _, err := fmt.Sscanf(resp.Header.Get("Range"), "bytes=0-%d", &committed); err != nil {
+			return 0 // Nothing committed yet
+		}
+		return min(committed+1-start, req.ContentLength)
+	}
+	return 0
+}
+
+// CurrentAccount returns the email of the account new requests are sent as.
+func (p *Pool) CurrentAccount() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.accounts[p.current].email
+}
+
+// SpareAccounts returns how many other accounts still have quota today.
+func (p *Pool) SpareAccounts() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	spare := 0
+	for i := range p.accounts {
+		if i != p.current && !p.exhaustedToday(i) {
+			spare++
+		}
+	}
+	return spare
+}
+
+// RotateAccount marks the account exhausted for today and, if it is still
+// the current one, switches to the next account with quota left. It returns
+// the account to continue with, or false if every other account is
+// exhausted; the account is then left unmarked, since with nothing to rotate
+// to the error may just as well be a rate limit that clears on its own.
+// Concurrent uploads that hit the quota together therefore rotate only once.
+func (p *Pool) RotateAccount(exhausted string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	spare := false
+	for i, account := range p.accounts {
+		if account.email != exhausted && !p.exhaustedToday(i) {
+			spare = true
+		}
+	}
+	if !spare {
+		return "", false
+	}
+	for i, account := range p.accounts {
+		if account.email == exhausted && !p.exhaustedToday(i) {
+			now := time.Now()
+			p.todayUsage(i).ExhaustedAt = &now
+			p.saveUsage()
+		}
+	}
+	for step := 0; step < len(p.accounts); step++ {
+		i := (p.current + step) % len(p.accounts)
+		if !p.exhaustedToday(i) {
+			p.current = i
+			return p.accounts[i].email, true
+		}
+	}
+	return "", false
+}
+
+// Status reports every account with today's usage, in bundle order.
+func (p *Pool) Status() []AccountStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	statuses := make([]AccountStatus, len(p.accounts))
+	for i, account := range p.accounts {
+		statuses[i] = AccountStatus{Email: account.email, Current: i == p.current, Today: *p.todayUsage(i)}
+	}
+	return statuses
+}
+
+// PoolFromClient returns the pool behind a client from GetAuthenticatedClient.
+func PoolFromClient(client *http.Client) (*Pool, bool) {
+	pool, ok := client.Transport.(*Pool)
+	return pool, ok
+}
+
+// recordUpload adds n uploaded bytes to an account's usage for today.
+func (p *Pool) recordUpload(index int, n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.todayUsage(index).UploadedBytes += n
+	p.saveUsage()
+}
+
+// exhaustedToday reports whether account i hit its quota today. Callers hold p.mu.
+func (p *Pool) exhaustedToday(i int) bool {
+	usage := p.usage[usageDay(time.Now())][p.accounts[i].email]
+	return usage != nil && usage.ExhaustedAt != nil
+}
+
+// todayUsage returns account i's usage record for today, creating it if
+// needed. Callers hold p.mu.
+func (p *Pool) todayUsage(i int) *AccountUsage {
+	day := usageDay(time.Now())
+	if p.usage[day] == nil {
+		p.usage[day] = map[string]*AccountUsage{}
+	}
+	email := p.accounts[i].email
+	if p.usage[day][email] == nil {
+		p.usage[day][email] = &AccountUsage{}
+	}
+	return p.usage[day][email]
+}
+
+// saveUsage writes the usage history, dropping days older than
+// usageRetentionDays. Failures are ignored. Callers hold p.mu.
+func (p *Pool) saveUsage() {
+	cutoff := usageDay(time.Now().AddDate(0, 0, -usageRetentionDays))
+	for day := range p.usage {
+		if day < cutoff {
+			delete(p.usage, day)
+		}
+	}
+	path, err := usagePath()
+	if err != nil {
+		return
+	}
+	_ = utils.WriteJSONFile(path, p.usage)
+}
+
+// usageDay keys usage by local calendar day.
+func usageDay(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func usagePath() (string, error) {
+	dir, err := utils.AppCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, USAGE_FILE), nil
+}
+
+func loadUsage() (map[string]map[string]*AccountUsage, error) {
+	path, err := usagePath()
+	if err != nil {
+		return nil, err
+	}
+	usage := map[string]map[string]*AccountUsage{}
+	if err := utils.ReadJSONFile(path, &usage); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return usage, nil
+}
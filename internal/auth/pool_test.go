@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/utils"
+)
+
+// accountTransport sends requests to server, tagged with the account they
+// were sent as.
+type accountTransport struct {
+	email  string
+	server *httptest.Server
+}
+
+func (t accountTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, _ := url.Parse(t.server.URL)
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+	req.Header.Set("X-Account", t.email)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newTestPool returns a pool of the given accounts whose usage lives in a
+// temporary cache directory, with the accounts in exhausted already marked
+// exhausted today.
+func newTestPool(t *testing.T, server *httptest.Server, emails []string, exhausted ...string) *Pool {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if len(exhausted) > 0 {
+		now := time.Now()
+		day := map[string]*AccountUsage{}
+		for _, email := range exhausted {
+			day[email] = &AccountUsage{ExhaustedAt: &now}
+		}
+		path, err := usagePath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := utils.WriteJSONFile(path, map[string]map[string]*AccountUsage{usageDay(now): day}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var accounts []*poolAccount
+	for _, email := range emails {
+		accounts = append(accounts, &poolAccount{email: email, transport: accountTransport{email, server}})
+	}
+	return newPool(accounts)
+}
+
+// exhaustedAccounts returns the accounts marked exhausted today in the usage file.
+func exhaustedAccounts(t *testing.T) []string {
+	t.Helper()
+	usage, err := loadUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var emails []string
+	for email, u := range usage[usageDay(time.Now())] {
+		if u.ExhaustedAt != nil {
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+func TestNewPoolSkipsExhaustedAccounts(t *testing.T) {
+	pool := newTestPool(t, nil, []string{"a", "b", "c"}, "a", "b")
+	if got := pool.CurrentAccount(); got != "c" {
+		t.Errorf("CurrentAccount = %q, want c", got)
+	}
+	if got := pool.SpareAccounts(); got != 0 {
+		t.Errorf("SpareAccounts = %d, want 0", got)
+	}
+}
+
+func TestRotateAccount(t *testing.T) {
+	tests := []struct {
+		name          string
+		accounts      []string
+		exhausted     []string // Marked before the pool is created
+		rotate        []string // Accounts passed to successive RotateAccount calls
+		wantNext      string
+		wantOK        bool
+		wantExhausted []string
+	}{
+		{name: "next account", accounts: []string{"a", "b", "c"}, rotate: []string{"a"}, wantNext: "b", wantOK: true, wantExhausted: []string{"a"}},
+		{name: "skips exhausted", accounts: []string{"a", "b", "c"}, exhausted: []string{"b"}, rotate: []string{"a"}, wantNext: "c", wantOK: true, wantExhausted: []string{"a", "b"}},
+		{name: "wraps around", accounts: []string{"a", "b", "c"}, exhausted: []string{"a"}, rotate: []string{"b", "c"}, wantExhausted: []string{"a", "b"}},
+		{name: "concurrent rotation", accounts: []string{"a", "b", "c"}, rotate: []string{"a", "a"}, wantNext: "b", wantOK: true, wantExhausted: []string{"a"}},
+		{name: "only account", accounts: []string{"a"}, rotate: []string{"a"}},
+		{name: "last account left", accounts: []string{"a", "b"}, exhausted: []string{"a"}, rotate: []string{"b"}, wantExhausted: []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newTestPool(t, nil, tt.accounts, tt.exhausted...)
+			var next string
+			var ok bool
+			for _, email := range tt.rotate {
+				next, ok = pool.RotateAccount(email)
+			}
+			if next != tt.wantNext || ok != tt.wantOK {
+				t.Errorf("RotateAccount = %q, %v; want %q, %v", next, ok, tt.wantNext, tt.wantOK)
+			}
+			if ok && pool.CurrentAccount() != next {
+				t.Errorf("CurrentAccount = %q, want %q", pool.CurrentAccount(), next)
+			}
+			got := exhaustedAccounts(t)
+			slices.Sort(got)
+			if strings.Join(got, ",") != strings.Join(tt.wantExhausted, ",") {
+				t.Errorf("exhausted accounts = %v, want %v", got, tt.wantExhausted)
+			}
+		})
+	}
+}
+
+func TestPoolRoutesSessionsAndRecordsUsage(t *testing.T) {
+	var sentAs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sentAs = append(sentAs, r.Header.Get("X-Account"))
+		if r.URL.Query().Get("upload_id") == "" {
+			w.Header().Set("Location", "http://"+r.Host+"/upload/drive/v3/files?uploadType=resumable&upload_id=s1")
+			return
+		}
+		w.Header().Set("Range", "bytes=0-9")
+		w.WriteHeader(http.StatusPermanentRedirect)
+	}))
+	defer server.Close()
+	pool := newTestPool(t, server, []string{"a", "b"})
+	client := pool.Client()
+
+	resp, err := client.Post(server.URL+"/upload/drive/v3/files?uploadType=resumable", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if _, ok := pool.RotateAccount("a"); !ok {
+		t.Fatal("RotateAccount(a) found no spare account")
+	}
+	// The chunk belongs to the session started as a, even after rotating.
+	req, _ := http.NewRequest(http.MethodPut, server.URL+"/upload/drive/v3/files?uploadType=resumable&upload_id=s1", strings.NewReader("0123456789"))
+	req.Header.Set("Content-Range", "bytes 0-9/20")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if strings.Join(sentAs, ",") != "a,a" {
+		t.Errorf("requests sent as %v, want [a a]", sentAs)
+	}
+	usage, err := loadUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := usage[usageDay(time.Now())]
+	if today["a"] == nil || today["a"].UploadedBytes != 10 || today["a"].ExhaustedAt == nil {
+		t.Errorf("usage for a = %+v, want 10 bytes and exhausted", today["a"])
+	}
+	path, _ := usagePath()
+	if filepath.Base(path) != USAGE_FILE {
+		t.Errorf("usage path = %s, want %s", path, USAGE_FILE)
+	}
+}
+
+func TestCommittedChunkBytes(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		contentRange string
+		rangeHeader  string
+		want         int64
+	}{
+		{name: "whole chunk kept", status: http.StatusPermanentRedirect, contentRange: "bytes 100-199/1000", rangeHeader: "bytes=0-199", want: 100},
+		{name: "part of chunk kept", status: http.StatusPermanentRedirect, contentRange: "bytes 100-199/1000", rangeHeader: "bytes=0-149", want: 50},
+		{name: "nothing of chunk kept", status: http.StatusPermanentRedirect, contentRange: "bytes 100-199/1000", rangeHeader: "bytes=0-99", want: 0},
+		{name: "nothing committed", status: http.StatusPermanentRedirect, contentRange: "bytes 0-99/1000", want: 0},
+		{name: "no content range", status: http.StatusPermanentRedirect, rangeHeader: "bytes=0-99", want: 0},
+		{name: "finished", status: http.StatusOK, contentRange: "bytes 900-999/1000", want: 100},
+		{name: "created", status: http.StatusCreated, want: 100},
+		{name: "failed", status: http.StatusServiceUnavailable, contentRange: "bytes 900-999/1000", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &http.Request{Header: http.Header{}, ContentLength: 100}
+			if tt.contentRange != "" {
+				req.Header.Set("Content-Range", tt.contentRange)
+			}
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.rangeHeader != "" {
+				resp.Header.Set("Range", tt.rangeHeader)
+			}
+			if got := committedChunkBytes(req, resp); got != tt.want {
+				t.Errorf("committedChunkBytes = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveUsageDropsOldDays(t *testing.T) {
+	pool := newTestPool(t, nil, []string{"a"})
+	old := usageDay(time.Now().AddDate(0, 0, -usageRetentionDays-1))
+	pool.usage[old] = map[string]*AccountUsage{"a": {UploadedBytes: 1}}
+	pool.recordUpload(0, 5)
+
+	usage, err := loadUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := usage[old]; ok {
+		t.Errorf("usage from %s was kept", old)
+	}
+	if got := usage[usageDay(time.Now())]["a"].UploadedBytes; got != 5 {
+		t.Errorf("today's usage = %d bytes, want 5", got)
+	}
+}
@@ -0,0 +1,370 @@
+// File: penguindex-go/internal/cli/cli.go
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Command is one node of the command tree. A command either runs itself or
+// dispatches to Subcommands; a parent with a Run is the fallback when no
+// subcommand is named (e.g. "trash" lists the trash).
+type Command struct {
+	Name        string
+	Summary     string // One line, shown in command lists
+	Args        string // Synopsis after the command path, e.g. "[-o <path>] <fileID_or_link>"
+	Help        string // Optional longer description for help
+	JSON        bool   // Whether the command honours the global -json flag
+	Subcommands []*Command
+	Hidden      bool // Left out of help and completion
+
+	// Run executes the command with the positional arguments left after flag
+	// parsing. Returning a UsageError prints the usage line as well.
+	Run func(args []string) error
+
+	// CompleteArgs returns candidates for the next positional argument given
+	// the ones already typed. FlagValues does the same for flag values.
+	CompleteArgs func(args []string) []string
+	FlagValues   map[string]func() []string
+
+	flags  *flag.FlagSet
+	parent *Command
+}
+
+// Flags returns the command's own flag set, creating it on first use.
+func (c *Command) Flags() *flag.FlagSet {
+	if c.flags == nil {
+		c.flags = flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	}
+	return c.flags
+}
+
+// Path returns the command names from the top level down, e.g. "trash empty".
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// Root returns the top-level command c belongs to.
+func (c *Command) Root() *Command {
+	if c.parent == nil {
+		return c
+	}
+	return c.parent.Root()
+}
+
+func (c *Command) sub(name string) *Command {
+	for _, sub := range c.Subcommands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// UsageError is a mistake in the command line rather than a failure.
+type UsageError struct{ msg string }
+
+func (e *UsageError) Error() string { return e.msg }
+
+// UsageErrorf returns a UsageError.
+func UsageErrorf(format string, a ...any) error {
+	return &UsageError{fmt.Sprintf(format, a...)}
+}
+
+// App is a program with global flags and a tree of commands.
+type App struct {
+	Name     string
+	Summary  string
+	Globals  *flag.FlagSet // Accepted before the command and after it
+	Commands []*Command
+
+	// GlobalFlagValues completes values of global flags.
+	GlobalFlagValues map[string]func() []string
+	// Before runs after all flags are parsed and before the command.
+	Before func(cmd *Command) error
+	// ErrorColor formats error messages.
+	ErrorColor func(format string, a ...any) string
+}
+
+// Run parses args (without the program name), runs the selected command and
+// returns the process exit code.
+func (a *App) Run(args []string) int {
+	a.link()
+	if a.ErrorColor == nil {
+		a.ErrorColor = fmt.Sprintf
+	}
+	a.Globals.Usage = func() { a.printHelp(os.Stderr, nil) }
+	if err := a.Globals.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	args = a.Globals.Args()
+	if len(args) == 0 {
+		a.printHelp(os.Stderr, nil)
+		return 1
+	}
+
+	cmd := a.command(args[0])
+	if cmd == nil {
+		fmt.Fprintln(os.Stderr, a.ErrorColor("Unknown command: %s", args[0]))
+		a.printHelp(os.Stderr, nil)
+		return 1
+	}
+	args = args[1:]
+	for len(cmd.Subcommands) > 0 {
+		args = a.skipGlobals(args)
+		if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+			if sub := cmd.sub(args[0]); sub != nil {
+				cmd, args = sub, args[1:]
+				continue
+			}
+		}
+		if cmd.Run != nil {
+			break
+		}
+		if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+			fmt.Fprintln(os.Stderr, a.ErrorColor("Unknown %s command: %s", cmd.Path(), args[0]))
+		}
+		a.printHelp(os.Stderr, cmd)
+		return 1
+	}
+
+	fs := a.commandFlags(cmd)
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return exitCode(err)
+	}
+	if a.Before != nil {
+		if err := a.Before(cmd); err != nil {
+			fmt.Fprintln(os.Stderr, a.ErrorColor("Error: %v", err))
+			return 1
+		}
+	}
+	if err := cmd.Run(positional); err != nil {
+		var usageErr *UsageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintln(os.Stderr, a.ErrorColor("Error: %v", err))
+			fmt.Fprintln(os.Stderr, a.usageLine(cmd))
+			return 1
+		}
+		fmt.Fprintln(os.Stderr, a.ErrorColor("%s command failed: %v", capitalize(cmd.Root().Name), err))
+		return 1
+	}
+	return 0
+}
+
+// link sets parent pointers and adds the built-in help and completion commands.
+func (a *App) link() {
+	if a.command("help") == nil {
+		a.Commands = append(a.Commands, a.helpCommand(), a.completionCommand(), a.completeCommand())
+	}
+	var walk func(parent *Command, cmds []*Command)
+	walk = func(parent *Command, cmds []*Command) {
+		for _, cmd := range cmds {
+			cmd.parent = parent
+			walk(cmd, cmd.Subcommands)
+		}
+	}
+	walk(nil, a.Commands)
+}
+
+func (a *App) command(name string) *Command {
+	for _, cmd := range a.Commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// commandFlags returns cmd's flag set with the global flags added, sharing
+// their values, so global flags may also follow the command.
+func (a *App) commandFlags(cmd *Command) *flag.FlagSet {
+	fs := cmd.Flags()
+	a.Globals.VisitAll(func(f *flag.Flag) {
+		if fs.Lookup(f.Name) == nil {
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() { a.printHelp(os.Stderr, cmd) }
+	return fs
+}
+
+// skipGlobals consumes global flags between a command and its subcommand,
+// e.g. "config -profile work show". Any other flag leaves args untouched for
+// the command's own flag set.
+func (a *App) skipGlobals(args []string) []string {
+	fs := flag.NewFlagSet(a.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	a.Globals.VisitAll(func(f *flag.Flag) { fs.Var(f.Value, f.Name, f.Usage) })
+	if err := fs.Parse(args); err != nil {
+		return args
+	}
+	return fs.Args()
+}
+
+// parseInterspersed parses flags anywhere among the positional arguments,
+// e.g. "ls movies -long". A lone "-" is positional and "--" ends the flags.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func exitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	return 1 // The flag package has already printed the error and usage
+}
+
+func (a *App) usageLine(cmd *Command) string {
+	line := fmt.Sprintf("Usage: %s %s", a.Name, cmd.Path())
+	switch {
+	case cmd.Args != "":
+		line += " " + cmd.Args
+	case len(cmd.Subcommands) > 0:
+		line += " <command>"
+	}
+	return line
+}
+
+// printHelp writes help for cmd, or for the whole program if cmd is nil.
+func (a *App) printHelp(w io.Writer, cmd *Command) {
+	if cmd == nil {
+		fmt.Fprintf(w, "Usage: %s [global flags] <command> [arguments]\n", a.Name)
+		if a.Summary != "" {
+			fmt.Fprintf(w, "\n%s\n", a.Summary)
+		}
+		fmt.Fprintln(w, "\nCommands:")
+		printCommandList(w, a.Commands)
+		fmt.Fprintln(w, "\nGlobal flags (before or after the command):")
+		a.Globals.SetOutput(w)
+		a.Globals.PrintDefaults()
+		fmt.Fprintf(w, "\nRun '%s help <command>' for more information on a command.\n", a.Name)
+		return
+	}
+
+	fmt.Fprintln(w, a.usageLine(cmd))
+	if cmd.Summary != "" {
+		fmt.Fprintf(w, "\n%s\n", cmd.Summary)
+	}
+	if cmd.Help != "" {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(cmd.Help))
+	}
+	if len(cmd.Subcommands) > 0 {
+		fmt.Fprintln(w, "\nCommands:")
+		printCommandList(w, cmd.Subcommands)
+	}
+	own := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	cmd.Flags().VisitAll(func(f *flag.Flag) {
+		if a.Globals.Lookup(f.Name) == nil {
+			own.Var(f.Value, f.Name, f.Usage)
+			own.Lookup(f.Name).DefValue = f.DefValue
+		}
+	})
+	if hasFlags(own) {
+		fmt.Fprintln(w, "\nFlags:")
+		own.SetOutput(w)
+		own.PrintDefaults()
+	}
+	fmt.Fprintf(w, "\nRun '%s help' for the global flags.\n", a.Name)
+}
+
+func printCommandList(w io.Writer, cmds []*Command) {
+	width := 0
+	for _, cmd := range cmds {
+		if !cmd.Hidden {
+			width = max(width, len(cmd.Name))
+		}
+	}
+	for _, cmd := range cmds {
+		if !cmd.Hidden {
+			fmt.Fprintf(w, "  %-*s  %s\n", width, cmd.Name, cmd.Summary)
+		}
+	}
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// helpCommand prints help for the program or a command path.
+func (a *App) helpCommand() *Command {
+	return &Command{
+		Name:    "help",
+		Summary: "Show help for a command",
+		Args:    "[<command> [<subcommand>]]",
+		Run: func(args []string) error {
+			if len(args) == 0 {
+				a.printHelp(os.Stdout, nil)
+				return nil
+			}
+			cmd := a.command(args[0])
+			for _, name := range args[min(1, len(args)):] {
+				if cmd == nil {
+					break
+				}
+				cmd = cmd.sub(name)
+			}
+			if cmd == nil || cmd.Hidden {
+				return UsageErrorf("unknown command %q", strings.Join(args, " "))
+			}
+			a.printHelp(os.Stdout, cmd)
+			return nil
+		},
+		CompleteArgs: func(args []string) []string {
+			cmds := a.Commands
+			for _, name := range args {
+				var next []*Command
+				for _, cmd := range cmds {
+					if cmd.Name == name {
+						next = cmd.Subcommands
+					}
+				}
+				cmds = next
+			}
+			return commandNames(cmds)
+		},
+	}
+}
+
+func commandNames(cmds []*Command) []string {
+	var names []string
+	for _, cmd := range cmds {
+		if !cmd.Hidden {
+			names = append(names, cmd.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,120 @@
+// File: penguindex-go/internal/cli/cli_test.go
+package cli
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		want       []string
+		wantLong   bool
+		wantOutput string
+		wantErr    bool
+	}{
+		{name: "no arguments", args: nil, want: nil},
+		{name: "flags first", args: []string{"-long", "movies"}, want: []string{"movies"}, wantLong: true},
+		{name: "flags after positional", args: []string{"movies", "-long"}, want: []string{"movies"}, wantLong: true},
+		{name: "flags between positional", args: []string{"a", "-o", "out.bin", "b"}, want: []string{"a", "b"}, wantOutput: "out.bin"},
+		{name: "flag with equals", args: []string{"a", "-o=out.bin"}, want: []string{"a"}, wantOutput: "out.bin"},
+		{name: "lone dash is positional", args: []string{"-", "-long"}, want: []string{"-"}, wantLong: true},
+		{name: "lone dash among flags", args: []string{"-o", "out.bin", "-", "b"}, want: []string{"-", "b"}, wantOutput: "out.bin"},
+		{name: "double dash ends flags", args: []string{"-long", "--", "-o", "-x"}, want: []string{"-o", "-x"}, wantLong: true},
+		{name: "double dash after positional", args: []string{"a", "--", "-long"}, want: []string{"a", "-long"}},
+		{name: "double dash alone", args: []string{"--"}, want: nil},
+		{name: "unknown flag", args: []string{"a", "-nope"}, wantErr: true},
+		{name: "missing value", args: []string{"a", "-o"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			long := fs.Bool("long", false, "")
+			output := fs.String("o", "", "")
+
+			got, err := parseInterspersed(fs, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseInterspersed(%q) = %q, want an error", tt.args, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseInterspersed(%q): %v", tt.args, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("positional = %q, want %q", got, tt.want)
+			}
+			if *long != tt.wantLong || *output != tt.wantOutput {
+				t.Errorf("-long = %v, -o = %q; want %v, %q", *long, *output, tt.wantLong, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRunAcceptsGlobalsAroundCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantCommand string
+		wantArgs    []string
+		wantProfile string
+		wantJSON    bool
+	}{
+		{name: "before", args: []string{"-profile", "work", "ls", "movies"}, wantCommand: "ls", wantArgs: []string{"movies"}, wantProfile: "work"},
+		{name: "after", args: []string{"ls", "movies", "-json", "-profile", "work"}, wantCommand: "ls", wantArgs: []string{"movies"}, wantProfile: "work", wantJSON: true},
+		{name: "mixed with command flags", args: []string{"-json", "ls", "-long", "-profile=work", "movies"}, wantCommand: "ls -long", wantArgs: []string{"movies"}, wantProfile: "work", wantJSON: true},
+		{name: "between command and subcommand", args: []string{"trash", "-profile", "work", "empty"}, wantCommand: "trash empty", wantArgs: nil, wantProfile: "work"},
+		{name: "after subcommand", args: []string{"trash", "empty", "-json"}, wantCommand: "trash empty", wantArgs: nil, wantJSON: true},
+		{name: "parent fallback", args: []string{"trash", "-json"}, wantCommand: "trash", wantArgs: nil, wantJSON: true},
+		{name: "not after double dash", args: []string{"ls", "--", "-json"}, wantCommand: "ls", wantArgs: []string{"-json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			globals := flag.NewFlagSet("pg", flag.ContinueOnError)
+			profile := globals.String("profile", "", "")
+			jsonOutput := globals.Bool("json", false, "")
+
+			var ran string
+			var gotArgs []string
+			record := func(name string) func([]string) error {
+				return func(args []string) error {
+					ran, gotArgs = name, args
+					return nil
+				}
+			}
+			ls := &Command{Name: "ls"}
+			long := ls.Flags().Bool("long", false, "")
+			ls.Run = func(args []string) error {
+				name := "ls"
+				if *long {
+					name += " -long"
+				}
+				return record(name)(args)
+			}
+			app := &App{
+				Name:    "pg",
+				Globals: globals,
+				Commands: []*Command{
+					ls,
+					{Name: "trash", Run: record("trash"), Subcommands: []*Command{{Name: "empty", Run: record("trash empty")}}},
+				},
+			}
+
+			if code := app.Run(tt.args); code != 0 {
+				t.Fatalf("Run(%q) exited with %d", tt.args, code)
+			}
+			if ran != tt.wantCommand || !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("ran %q with %q, want %q with %q", ran, gotArgs, tt.wantCommand, tt.wantArgs)
+			}
+			if *profile != tt.wantProfile || *jsonOutput != tt.wantJSON {
+				t.Errorf("-profile = %q, -json = %v; want %q, %v", *profile, *jsonOutput, tt.wantProfile, tt.wantJSON)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// File: penguindex-go/internal/cli/completion.go
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// COMPLETE_COMMAND is the hidden command the completion scripts call with the
+// words typed so far; it prints one candidate per line. Keeping the logic in
+// the binary lets the scripts complete folder aliases from the live config.
+const COMPLETE_COMMAND = "__complete"
+
+// completionCommand prints a completion script for a shell.
+func (a *App) completionCommand() *Command {
+	cmd := &Command{
+		Name:    "completion",
+		Summary: "Print a shell completion script",
+		Help: fmt.Sprintf(`Load it from your shell profile, e.g.:
+  bash: source <(%[1]s completion bash)
+  zsh:  source <(%[1]s completion zsh)
+  fish: %[1]s completion fish > ~/.config/fish/completions/%[1]s.fish`, a.Name),
+	}
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		cmd.Subcommands = append(cmd.Subcommands, &Command{
+			Name:    shell,
+			Summary: fmt.Sprintf("Print the %s completion script", shell),
+			Run: func(args []string) error {
+				if len(args) > 0 {
+					return UsageErrorf("unexpected arguments: %s", strings.Join(args, " "))
+				}
+				fmt.Print(a.completionScript(shell))
+				return nil
+			},
+		})
+	}
+	return cmd
+}
+
+func (a *App) completeCommand() *Command {
+	return &Command{
+		Name:   COMPLETE_COMMAND,
+		Hidden: true,
+		Run: func(args []string) error {
+			for _, candidate := range a.complete(args) {
+				fmt.Println(candidate)
+			}
+			return nil
+		},
+	}
+}
+
+var identifierUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func (a *App) completionScript(shell string) string {
+	fn := "_" + identifierUnsafe.ReplaceAllString(a.Name, "_")
+	switch shell {
+	case "bash":
+		return fmt.Sprintf(`# bash completion for %[1]s
+%[2]s() {
+    local IFS=$'\n'
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    COMPREPLY=($(compgen -W "$("${COMP_WORDS[0]}" %[3]s -- "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)" -- "$cur"))
+}
+complete -o default -F %[2]s %[1]s
+`, a.Name, fn, COMPLETE_COMMAND)
+	case "zsh":
+		return fmt.Sprintf(`#compdef %[1]s
+# zsh completion for %[1]s
+%[2]s() {
+    local -a candidates
+    candidates=("${(@f)$("${words[1]}" %[3]s -- "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    if (( ${#candidates} )); then
+        compadd -a candidates
+    else
+        _files
+    fi
+}
+compdef %[2]s %[1]s
+`, a.Name, fn, COMPLETE_COMMAND)
+	default:
+		return fmt.Sprintf(`# fish completion for %[1]s
+function %[2]s
+    set -l tokens (commandline -opc) (commandline -ct)
+    $tokens[1] %[3]s -- $tokens[2..-1] 2>/dev/null
+end
+complete -c %[1]s -a '(%[2]s)'
+`, a.Name, fn, COMPLETE_COMMAND)
+	}
+}
+
+// complete returns the candidates for the last of words, the command line
+// after the program name. Global flag values among the words are applied, so
+// completers see the chosen -config and -profile.
+func (a *App) complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current, prior := words[len(words)-1], words[:len(words)-1]
+
+	var cmd *Command
+	var positional []string
+	var pending *flag.Flag // Flag whose value is the next word
+	lookup := func(name string) *flag.Flag {
+		if cmd != nil {
+			if f := cmd.Flags().Lookup(name); f != nil {
+				return f
+			}
+		}
+		return a.Globals.Lookup(name)
+	}
+	for i, word := range prior {
+		if pending != nil {
+			a.applyGlobal(pending.Name, word)
+			pending = nil
+			continue
+		}
+		if word == "--" {
+			positional = append(positional, prior[i+1:]...)
+			break
+		}
+		if strings.HasPrefix(word, "-") && word != "-" {
+			name, value, hasValue := strings.Cut(strings.TrimLeft(word, "-"), "=")
+			f := lookup(name)
+			switch {
+			case f == nil:
+			case hasValue:
+				a.applyGlobal(name, value)
+			case !isBoolFlag(f):
+				pending = f
+			}
+			continue
+		}
+		switch {
+		case cmd == nil:
+			cmd = a.command(word)
+			if cmd == nil {
+				return nil
+			}
+		case len(positional) == 0 && cmd.sub(word) != nil:
+			cmd = cmd.sub(word)
+		default:
+			positional = append(positional, word)
+		}
+	}
+
+	var candidates []string
+	switch {
+	case pending != nil:
+		candidates = a.flagValues(cmd, pending.Name)
+	case strings.HasPrefix(current, "-"):
+		seen := map[string]bool{}
+		add := func(f *flag.Flag) {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				candidates = append(candidates, "-"+f.Name)
+			}
+		}
+		if cmd != nil {
+			cmd.Flags().VisitAll(add)
+		}
+		a.Globals.VisitAll(add)
+	case cmd == nil:
+		candidates = commandNames(a.Commands)
+	default:
+		if len(positional) == 0 {
+			candidates = commandNames(cmd.Subcommands)
+		}
+		if cmd.CompleteArgs != nil {
+			candidates = append(candidates, cmd.CompleteArgs(positional)...)
+		}
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) {
+			matches = append(matches, candidate)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// applyGlobal sets a global flag seen on the command line being completed.
+func (a *App) applyGlobal(name, value string) {
+	if a.Globals.Lookup(name) != nil {
+		_ = a.Globals.Set(name, value)
+	}
+}
+
+func (a *App) flagValues(cmd *Command, name string) []string {
+	if cmd != nil && cmd.FlagValues[name] != nil {
+		return cmd.FlagValues[name]()
+	}
+	if a.GlobalFlagValues[name] != nil {
+		return a.GlobalFlagValues[name]()
+	}
+	return nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
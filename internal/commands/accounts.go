@@ -0,0 +1,42 @@
+// File: penguindex-go/internal/commands/accounts.go
+package commands
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jendermine/penguindex-go/internal/auth"
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"github.com/fatih/color"
+)
+
+// HandleAccountsList prints the service accounts in the bundle with the
+// bytes each uploaded today and whether it has hit its daily upload quota.
+// Usage is tracked locally, so uploads from other machines are not counted.
+func HandleAccountsList(httpClient *http.Client, jsonOutput bool) error {
+	pool, ok := auth.PoolFromClient(httpClient)
+	if !ok {
+		return fmt.Errorf("client is not backed by a service account pool")
+	}
+
+	statuses := pool.Status()
+	if jsonOutput {
+		return printJSON(statuses)
+	}
+	fmt.Printf("%-2s %-60s  %12s  %s\n", "", "ACCOUNT", "TODAY", "STATUS")
+	exhausted := 0
+	for _, status := range statuses {
+		marker := ""
+		if status.Current {
+			marker = "*"
+		}
+		state := color.GreenString("available")
+		if status.Today.ExhaustedAt != nil {
+			exhausted++
+			state = color.RedString("exhausted since %s", status.Today.ExhaustedAt.Format("15:04"))
+		}
+		fmt.Printf("%-2s %-60s  %12s  %s\n", marker, status.Email, utils.HumanReadableSize(uint64(status.Today.UploadedBytes)), state)
+	}
+	fmt.Println(color.CyanString("%d accounts, %d exhausted today. * marks the account in use.", len(statuses), exhausted))
+	return nil
+}
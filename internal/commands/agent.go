@@ -0,0 +1,71 @@
+// File: penguindex-go/internal/commands/agent.go
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/agent"
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"github.com/fatih/color"
+)
+
+// HandleAgentStart runs the agent in the foreground. It starts locked; the
+// next command that decrypts the bundle caches it there.
+func HandleAgentStart(idle time.Duration) error {
+	if idle <= 0 {
+		return fmt.Errorf("-idle must be positive")
+	}
+	path, err := agent.SocketPath()
+	if err != nil {
+		return err
+	}
+	utils.Status(color.CyanString("Agent listening on %s (idle timeout %s). Stop it with Ctrl+C or 'agent stop'.", path, idle))
+	utils.Status("The next command that asks for the PIN will cache the decrypted bundle here.")
+	if err := agent.Serve(idle); err != nil {
+		return err
+	}
+	utils.Status(color.GreenString("Agent stopped, cached bundle wiped."))
+	return nil
+}
+
+// HandleAgentStatus prints whether an agent is running and what it holds.
+func HandleAgentStatus(jsonOutput bool) error {
+	status, err := agent.GetStatus()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(status)
+	}
+	fmt.Printf("Agent PID: %d\n", status.PID)
+	if status.Locked {
+		fmt.Println("State: " + color.YellowString("locked (no bundle cached)"))
+		return nil
+	}
+	fmt.Println("State: " + color.GreenString("unlocked"))
+	fmt.Printf("Bundle: %s\n", status.BundleURL)
+	fmt.Printf("Locks at: %s (after %s idle)\n", status.ExpiresAt.Format("15:04:05"), time.Until(status.ExpiresAt).Round(time.Second))
+	if !status.Mlocked {
+		utils.Warn(color.YellowString("Warning: the bundle could not be locked in memory and may be swapped to disk."))
+	}
+	return nil
+}
+
+// HandleAgentLock makes the agent forget the bundle without stopping it.
+func HandleAgentLock() error {
+	if err := agent.Lock(); err != nil {
+		return err
+	}
+	utils.Status(color.GreenString("Agent locked; the next command will ask for the PIN again."))
+	return nil
+}
+
+// HandleAgentStop wipes the bundle and stops the agent.
+func HandleAgentStop() error {
+	if err := agent.Stop(); err != nil {
+		return err
+	}
+	utils.Status(color.GreenString("Agent stopped."))
+	return nil
+}
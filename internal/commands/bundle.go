@@ -0,0 +1,298 @@
+// File: penguindex-go/internal/commands/bundle.go
+package commands
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jendermine/penguindex-go/internal/config"
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// BundleWriteOptions control how encrypt and rekey write the new bundle.
+type BundleWriteOptions struct {
+	OutPath     string // Destination, or "-" for stdout
+	NewPINFile  string
+	KDF         config.KDFParams
+	SignKeyFile string // Sign the new bundle with this key from 'bundle keygen'
+	SignURL     string // URL the signed bundle is published at; empty allows any
+}
+
+// BundleEncryptOptions are the inputs of HandleBundleEncrypt.
+type BundleEncryptOptions struct {
+	BundleWriteOptions
+	ServiceAccountFiles []string // Key files; the first is the primary account
+	TokenFile           string   // File holding the Telegram bot token; prompted for if empty
+}
+
+// HandleBundleEncrypt builds an encrypted bundle in the format DecryptBundle
+// reads from service account key files and a Telegram bot token.
+func HandleBundleEncrypt(opts BundleEncryptOptions) error {
+	bundle := &config.DecryptedBundle{}
+	for i, path := range opts.ServiceAccountFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read service account key: %w", err)
+		}
+		info, err := config.ParseServiceAccount(string(data))
+		if err != nil {
+			return fmt.Errorf("%s is not a service account key: %w", path, err)
+		}
+		if !utils.Quiet { // On stderr: the bundle itself may be written to stdout
+			fmt.Fprintln(os.Stderr, color.CyanString("Adding service account %s (project %s)", info.ClientEmail, info.ProjectID))
+		}
+		if i == 0 {
+			bundle.ServiceAccountJSONString = string(data)
+		} else {
+			bundle.ServiceAccountJSONStrings = append(bundle.ServiceAccountJSONStrings, string(data))
+		}
+	}
+
+	token, err := readBotToken(opts.TokenFile)
+	if err != nil {
+		return err
+	}
+	bundle.TelegramBotToken = token
+
+	if err := writeBundle(bundle, opts.BundleWriteOptions); err != nil {
+		return err
+	}
+	if opts.OutPath != "-" {
+		utils.Status(color.GreenString("Encrypted bundle written to %s (%s)", opts.OutPath, opts.KDF))
+	}
+	return nil
+}
+
+// HandleBundleDecrypt decrypts the bundle at source (a file or URL) to check
+// the PIN and contents, and prints only non-secret metadata.
+func HandleBundleDecrypt(source string, verifier *config.Verifier, pinOpts config.PINOptions) error {
+	remoteBundle, err := config.LoadEncryptedBundle(source, verifier)
+	if err != nil {
+		return err
+	}
+	encrypted := remoteBundle.EncryptedBundle
+	pin, pinSource, err := config.ReadPIN(pinOpts)
+	if err != nil {
+		return err
+	}
+	bundle, err := config.DecryptBundle(encrypted, pin)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt bundle with PIN from %s: %w", pinSource, err)
+	}
+	fmt.Println(color.GreenString("Bundle %s decrypted successfully.", source))
+	header, err := config.InspectBundle(encrypted)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Format: version %d, %s\n", header.Version, header.KDF)
+	if header.Version == config.BUNDLE_VERSION_LEGACY {
+		utils.Warn(color.YellowString("Warning: legacy bundle without an authenticated header; upgrade it with 'bundle rekey' (the new PIN may be the same)."))
+	}
+	return printBundleInfo(bundle)
+}
+
+// HandleBundleRekey re-encrypts the bundle at source under a new PIN and
+// opts.KDF, which also upgrades legacy bundles. The plaintext only ever
+// exists in memory. The result never replaces source: a mistyped new PIN
+// would otherwise lose the bundle. A signed source must be re-signed, since
+// publishing the output unsigned would lock out every verifying client.
+func HandleBundleRekey(source string, verifier *config.Verifier, pinOpts config.PINOptions, opts BundleWriteOptions) error {
+	if opts.OutPath == "" {
+		return fmt.Errorf("-o is required")
+	}
+	if !config.IsRemoteSource(source) && sameFile(source, opts.OutPath) {
+		return fmt.Errorf("refusing to overwrite %s in place; write to another file, check it with 'bundle decrypt', then replace the original", source)
+	}
+	remoteBundle, err := config.LoadEncryptedBundle(source, verifier)
+	if err != nil {
+		return err
+	}
+	if remoteBundle.Signature != "" && opts.SignKeyFile == "" {
+		return fmt.Errorf("%s is signed; pass -sign-key so the re-encrypted bundle is signed too", source)
+	}
+	if opts.SignURL == "" {
+		opts.SignURL = remoteBundle.URL // Keep the source's URL binding
+	}
+	pin, pinSource, err := config.ReadPIN(pinOpts)
+	if err != nil {
+		return err
+	}
+	bundle, err := config.DecryptBundle(remoteBundle.EncryptedBundle, pin)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt bundle with PIN from %s: %w", pinSource, err)
+	}
+	if err := writeBundle(bundle, opts); err != nil {
+		return err
+	}
+	if opts.OutPath != "-" {
+		utils.Status(color.GreenString("Bundle re-encrypted with the new PIN (%s) and written to %s", opts.KDF, opts.OutPath))
+	}
+	return nil
+}
+
+// writeBundle encrypts bundle under a newly entered PIN, signs it if a key
+// was given and writes it out. The key is loaded first so a bad key file
+// fails before the PIN prompt.
+func writeBundle(bundle *config.DecryptedBundle, opts BundleWriteOptions) error {
+	var signKey ed25519.PrivateKey
+	if opts.SignKeyFile != "" {
+		var err error
+		if signKey, err = config.LoadSigningKey(opts.SignKeyFile); err != nil {
+			return err
+		}
+	}
+	pin, err := config.ReadNewPIN(opts.NewPINFile)
+	if err != nil {
+		return err
+	}
+	encrypted, err := config.EncryptBundle(bundle, pin, opts.KDF)
+	if err != nil {
+		return err
+	}
+	remoteBundle := &config.RemoteEncryptedBundle{EncryptedBundle: encrypted}
+	if signKey != nil {
+		if remoteBundle, err = config.SignBundle(signKey, encrypted, opts.SignURL); err != nil {
+			return err
+		}
+	}
+	return config.WriteEncryptedBundle(opts.OutPath, remoteBundle)
+}
+
+// HandleBundleKeygen creates a signing key pair for bundles and chat IDs and
+// prints the public key to configure as signing_public_key.
+func HandleBundleKeygen(keyPath string) error {
+	publicKey, err := config.GenerateSigningKey(keyPath)
+	if err != nil {
+		return err
+	}
+	fmt.Println(color.GreenString("Signing key written to %s. Keep it off the machines that only upload.", keyPath))
+	fmt.Printf("Public key: %s\n", publicKey)
+	fmt.Println("Set it as signing_public_key in the config file, $" + config.ENV_SIGNING_KEY + ", or compile it in.")
+	return nil
+}
+
+// HandleBundleSign adds a signature to the bundle file at source without
+// decrypting it, writing the result to outPath (default: source). signURL is
+// the URL the signed bundle is published at; empty keeps the source's.
+func HandleBundleSign(source, keyFile, outPath, signURL string) error {
+	if outPath == "" {
+		if config.IsRemoteSource(source) {
+			return fmt.Errorf("-o is required when the bundle comes from a URL")
+		}
+		outPath = source
+	}
+	signKey, err := config.LoadSigningKey(keyFile)
+	if err != nil {
+		return err
+	}
+	remoteBundle, err := config.LoadEncryptedBundle(source, nil)
+	if err != nil {
+		return err
+	}
+	encrypted := remoteBundle.EncryptedBundle
+	if _, err := config.InspectBundle(encrypted); err != nil {
+		return fmt.Errorf("refusing to sign an unreadable bundle: %w", err)
+	}
+	if signURL == "" {
+		signURL = remoteBundle.URL // Keep the source's URL binding
+	}
+	remoteBundle, err = config.SignBundle(signKey, encrypted, signURL)
+	if err != nil {
+		return err
+	}
+	if err := config.WriteEncryptedBundle(outPath, remoteBundle); err != nil {
+		return err
+	}
+	if outPath != "-" {
+		utils.Status(color.GreenString("Signed bundle written to %s (public key %s)", outPath, config.PublicKeyOf(signKey)))
+	}
+	return nil
+}
+
+// HandleBundleSignChatID writes a signed chat ID document for the chat ID
+// URL signURL (empty allows any).
+func HandleBundleSignChatID(chatID, keyFile, outPath, signURL string) error {
+	chatID, err := config.NormalizeChatID(chatID)
+	if err != nil {
+		return err
+	}
+	signKey, err := config.LoadSigningKey(keyFile)
+	if err != nil {
+		return err
+	}
+	signed, err := config.SignChatID(signKey, chatID, signURL)
+	if err != nil {
+		return err
+	}
+	if err := config.WriteSignedChatID(outPath, signed); err != nil {
+		return err
+	}
+	if outPath != "-" {
+		utils.Status(color.GreenString("Signed chat ID written to %s (public key %s)", outPath, config.PublicKeyOf(signKey)))
+	}
+	return nil
+}
+
+// printBundleInfo lists the accounts and bot in bundle without any secrets.
+func printBundleInfo(bundle *config.DecryptedBundle) error {
+	accounts := bundle.ServiceAccounts()
+	if len(accounts) == 0 {
+		return fmt.Errorf("bundle contains no service account")
+	}
+	var invalid int
+	for i, account := range accounts {
+		info, err := config.ParseServiceAccount(account)
+		if err != nil {
+			invalid++
+			fmt.Printf("Service account %d: %s\n", i+1, color.RedString("invalid: %v", err))
+			continue
+		}
+		fmt.Printf("Service account %d: %s (project %s, key ID %s)\n", i+1, info.ClientEmail, info.ProjectID, info.PrivateKeyID)
+	}
+	switch botID := config.TelegramBotID(bundle.TelegramBotToken); {
+	case bundle.TelegramBotToken == "":
+		fmt.Println("Telegram bot: " + color.YellowString("not set, notifications are disabled"))
+	case botID == "":
+		fmt.Println("Telegram bot: " + color.RedString("token is malformed"))
+	default:
+		fmt.Printf("Telegram bot: ID %s\n", botID)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d service accounts are invalid", invalid, len(accounts))
+	}
+	return nil
+}
+
+// readBotToken reads the Telegram bot token from tokenFile, or prompts for
+// it without echo. An empty token disables notifications.
+func readBotToken(tokenFile string) (string, error) {
+	if tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read bot token: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no -token-file was given and stdin is not a terminal")
+	}
+	// Prompt on stderr: with -o - the bundle itself goes to stdout.
+	fmt.Fprint(os.Stderr, "Telegram bot token (empty for none): ")
+	token, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read bot token: %w", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// sameFile reports whether a and b name the same existing file.
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
@@ -0,0 +1,112 @@
+// File: penguindex-go/internal/commands/config.go
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jendermine/penguindex-go/internal/config"
+	"github.com/fatih/color"
+)
+
+// configShowOutput is the -json output schema of config show. Settings are
+// keyed by their config file names.
+type configShowOutput struct {
+	ConfigPath   string                           `json:"config_path"`
+	ConfigLoaded bool                             `json:"config_loaded"`
+	Settings     map[string]config.Setting        `json:"settings"`
+	Folders      map[string]string                `json:"folders"`
+	Retention    map[string]*config.RetentionRule `json:"retention"`
+}
+
+// HandleConfigShow prints the resolved local settings and where each one came from.
+// It needs no network access or PIN.
+func HandleConfigShow(settings *config.Settings, jsonOutput bool) error {
+	rows := []struct {
+		key     string
+		label   string
+		setting config.Setting
+	}{
+		{"profile", "Profile:", settings.Profile},
+		{"bundle_url", "Bundle URL:", settings.BundleURL},
+		{"chat_id_url", "Chat ID URL:", settings.ChatIDURL},
+		{"default_folder_id", "Default folder ID:", settings.DefaultFolderID},
+		{"ddl_base_url", "DDL base URL:", settings.DDLBaseURL},
+		{"signing_public_key", "Signing key:", settings.SigningPublicKey},
+		{"bundle_sha256", "Bundle SHA-256:", settings.BundleSHA256},
+		{"chat_id_sha256", "Chat ID SHA-256:", settings.ChatIDSHA256},
+		{"config_max_staleness", "Max staleness:", settings.ConfigMaxStaleness},
+		{"proxy", "Proxy:", settings.Proxy},
+		{"ca_file", "CA file:", settings.CAFile},
+		{"connect_timeout", "Connect timeout:", settings.ConnectTimeout},
+		{"read_timeout", "Read timeout:", settings.ReadTimeout},
+	}
+
+	if jsonOutput {
+		output := configShowOutput{
+			ConfigPath:   settings.ConfigPath,
+			ConfigLoaded: settings.ConfigLoaded,
+			Settings:     map[string]config.Setting{},
+			Folders:      map[string]string{},
+			Retention:    map[string]*config.RetentionRule{},
+		}
+		for _, row := range rows {
+			output.Settings[row.key] = row.setting
+		}
+		for alias, folderID := range settings.FolderAliases() {
+			output.Folders[alias] = folderID
+		}
+		for key, rule := range settings.RetentionRules() {
+			output.Retention[key] = rule
+		}
+		return printJSON(output)
+	}
+
+	valueColor := color.New(color.FgGreen).SprintfFunc()
+	sourceColor := color.New(color.FgHiBlack).SprintfFunc()
+
+	configState := "loaded"
+	if !settings.ConfigLoaded {
+		configState = "not found, using defaults"
+	}
+	fmt.Printf("%-18s %s %s\n", "Config file:", valueColor(settings.ConfigPath), sourceColor("(%s)", configState))
+	for _, row := range rows {
+		value := row.setting.Value
+		if value == "" {
+			value = "(none)"
+		}
+		fmt.Printf("%-18s %s %s\n", row.label, valueColor(value), sourceColor("(from %s)", row.setting.Source))
+	}
+
+	aliases := settings.FolderAliases()
+	if len(aliases) > 0 {
+		fmt.Println("Folder aliases:")
+		names := make([]string, 0, len(aliases))
+		for alias := range aliases {
+			names = append(names, alias)
+		}
+		sort.Strings(names)
+		for _, alias := range names {
+			fmt.Printf("  %-16s %s\n", alias, valueColor(aliases[alias]))
+		}
+	}
+
+	rules := settings.RetentionRules()
+	if len(rules) > 0 {
+		fmt.Println("Retention rules:")
+		keys := make([]string, 0, len(rules))
+		for key := range rules {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			rule := rules[key]
+			if rule == nil {
+				fmt.Printf("  %-16s %s\n", key, color.RedString("(empty rule)"))
+				continue
+			}
+			fmt.Printf("  %-16s %s\n", key, valueColor("max_age_days=%d max_total_size=%q keep_newest=%d", rule.MaxAgeDays, rule.MaxTotalSize, rule.KeepNewest))
+		}
+	}
+	return nil
+}
@@ -6,23 +6,35 @@ import (
 
 	"github.com/jendermine/penguindex-go/internal/config"
 	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"github.com/jendermine/penguindex-go/internal/utils"
 	"google.golang.org/api/drive/v3"
 	"github.com/fatih/color"
 )
 
-// HandleDelete orchestrates the file deletion process.
-func HandleDelete(driveSvc *drive.Service, _ *config.AppConfig, fileIDOrLink string) error {
+// HandleDelete orchestrates the file deletion process. Files are moved to the
+// trash unless permanent is set, in which case they are deleted for good.
+func HandleDelete(driveSvc *drive.Service, _ *config.AppConfig, fileIDOrLink string, permanent bool) error {
 	infoColor := color.New(color.FgCyan).SprintfFunc()
 	successColor := color.New(color.FgGreen).SprintfFunc()
 
-	fmt.Println(infoColor("Attempting to extract File ID from: %s", fileIDOrLink))
+	utils.Status(infoColor("Attempting to extract File ID from: %s", fileIDOrLink))
 	actualFileID, err := gdrive.ExtractFileID(fileIDOrLink)
 	if err != nil {
 		return fmt.Errorf("invalid file ID or link: %w", err)
 	}
-	fmt.Println(infoColor("Extracted File ID: %s", actualFileID))
+	utils.Status(infoColor("Extracted File ID: %s", actualFileID))
 
-	fmt.Println(infoColor("Attempting to delete file with ID: %s", actualFileID))
+	if !permanent {
+		utils.Status(infoColor("Moving file with ID %s to trash", actualFileID))
+		trashed, err := gdrive.TrashDriveFile(driveSvc, actualFileID)
+		if err != nil {
+			return fmt.Errorf("delete failed for ID '%s': %w", actualFileID, err)
+		}
+		utils.Status(successColor("Moved '%s' (%s) to trash. Undo with: restore %s", trashed.Name, trashed.Id, trashed.Id))
+		return nil
+	}
+
+	utils.Status(color.YellowString("Permanently deleting file with ID: %s (this cannot be undone)", actualFileID))
 	err = gdrive.DeleteDriveFile(driveSvc, actualFileID)
 	if err != nil {
 		// Check if the error is a "file not found" type to provide a better message
@@ -33,7 +45,7 @@ func HandleDelete(driveSvc *drive.Service, _ *config.AppConfig, fileIDOrLink str
 		return fmt.Errorf("delete failed for ID '%s': %w", actualFileID, err)
 	}
 
-	fmt.Println(successColor("Successfully deleted file with ID: %s", actualFileID))
+	utils.Status(successColor("Successfully deleted file with ID: %s", actualFileID))
 	// Optionally, send a Telegram notification about the deletion here if desired.
 	return nil
 }
@@ -0,0 +1,238 @@
+// File: penguindex-go/internal/commands/delete_bulk.go
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"github.com/fatih/color"
+)
+
+// BulkDeleteOptions selects the files for HandleBulkDelete and how to delete them.
+// Entries from every source are combined and de-duplicated.
+type BulkDeleteOptions struct {
+	Entries   []string           // IDs or links given directly
+	FromFile  string             // File with one ID or link per line
+	FromStdin bool               // Read IDs or links from stdin ("delete -")
+	Query     string             // Drive search query, e.g. "name contains 'tmp'"
+	Scope     gdrive.SearchScope // Where Query searches; My Drive and shared items by default
+	Permanent bool               // Skip the trash
+	AssumeYes bool               // Do not ask for confirmation
+}
+
+// deleteTarget is one resolved entry of a bulk delete.
+type deleteTarget struct {
+	Entry string      // As given by the user, for reporting
+	File  *drive.File // nil if the entry could not be resolved
+	Err   error
+}
+
+// HandleBulkDelete resolves every entry to a Drive file, prints a preview
+// table, asks for confirmation and then deletes (or trashes) each file,
+// reporting per item. It fails if any entry could not be resolved or deleted.
+func HandleBulkDelete(driveSvc *drive.Service, opts BulkDeleteOptions) error {
+	infoColor := color.New(color.FgCyan).SprintfFunc()
+	successColor := color.New(color.FgGreen).SprintfFunc()
+	errorColor := color.New(color.FgRed).SprintfFunc()
+
+	entries := append([]string{}, opts.Entries...)
+	if opts.FromFile != "" {
+		file, err := os.Open(opts.FromFile)
+		if err != nil {
+			return fmt.Errorf("failed to open list file: %w", err)
+		}
+		fileEntries, err := readDeleteEntries(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", opts.FromFile, err)
+		}
+		entries = append(entries, fileEntries...)
+	}
+	if opts.FromStdin {
+		stdinEntries, err := readDeleteEntries(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read IDs from stdin: %w", err)
+		}
+		entries = append(entries, stdinEntries...)
+	}
+
+	targets := resolveDeleteTargets(driveSvc, entries)
+	if opts.Query != "" {
+		utils.Status(infoColor("Searching Drive for: %s", opts.Query))
+		matches, err := gdrive.SearchFiles(driveSvc, opts.Query, opts.Scope)
+		if err != nil {
+			return err
+		}
+		seen := map[string]bool{}
+		for _, target := range targets {
+			if target.File != nil {
+				seen[target.File.Id] = true
+			}
+		}
+		for _, match := range matches {
+			if !seen[match.Id] {
+				seen[match.Id] = true
+				targets = append(targets, deleteTarget{Entry: match.Id, File: match})
+			}
+		}
+	}
+
+	var resolved int
+	for _, target := range targets {
+		if target.File != nil {
+			resolved++
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Println("Nothing to delete.")
+		return nil
+	}
+	printDeletePreview(targets)
+	printDriveCounts(driveSvc, targets)
+
+	action := "Move %d files to trash?"
+	if opts.Permanent {
+		action = "Permanently delete %d files? This cannot be undone."
+	}
+	if resolved > 0 && !opts.AssumeYes {
+		// With IDs piped on stdin, the answer has to come from the terminal.
+		in := io.Reader(os.Stdin)
+		if opts.FromStdin {
+			tty, err := os.Open("/dev/tty")
+			if err != nil {
+				return fmt.Errorf("cannot ask for confirmation while reading IDs from stdin; pass -yes")
+			}
+			defer tty.Close()
+			in = tty
+		}
+		if !confirm(in, fmt.Sprintf(action, resolved)) {
+			return fmt.Errorf("aborted")
+		}
+	}
+
+	var deleted, failed int
+	for _, target := range targets {
+		if target.File == nil {
+			failed++
+			fmt.Println(errorColor("FAILED  %s: %v", target.Entry, target.Err))
+			continue
+		}
+		var err error
+		if opts.Permanent {
+			err = gdrive.DeleteDriveFile(driveSvc, target.File.Id)
+		} else {
+			_, err = gdrive.TrashDriveFile(driveSvc, target.File.Id)
+		}
+		if err != nil {
+			failed++
+			fmt.Println(errorColor("FAILED  %s (%s): %v", target.File.Name, target.File.Id, err))
+			continue
+		}
+		deleted++
+		fmt.Println(successColor("OK      %s (%s)", target.File.Name, target.File.Id))
+	}
+
+	verb := "Trashed"
+	if opts.Permanent {
+		verb = "Deleted"
+	}
+	fmt.Printf("%s %d, failed %d.\n", verb, deleted, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d deletions failed", failed, len(targets))
+	}
+	return nil
+}
+
+// readDeleteEntries reads one ID or link per line, ignoring blank lines and
+// lines starting with '#'.
+func readDeleteEntries(r io.Reader) ([]string, error) {
+	var entries []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries, scanner.Err()
+}
+
+// resolveDeleteTargets runs every entry through ExtractFileID and fetches its
+// metadata for the preview. Entries that fail keep their error for the report.
+func resolveDeleteTargets(driveSvc *drive.Service, entries []string) []deleteTarget {
+	var targets []deleteTarget
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		fileID, err := gdrive.ExtractFileID(entry)
+		if err != nil {
+			targets = append(targets, deleteTarget{Entry: entry, Err: err})
+			continue
+		}
+		if seen[fileID] {
+			continue
+		}
+		seen[fileID] = true
+		file, err := gdrive.GetFileMetadata(driveSvc, fileID)
+		targets = append(targets, deleteTarget{Entry: entry, File: file, Err: err})
+	}
+	return targets
+}
+
+// printDeletePreview prints what is about to be deleted, and what cannot be.
+func printDeletePreview(targets []deleteTarget) {
+	fmt.Printf("%-40s  %-33s  %10s  %s\n", "NAME", "ID", "SIZE", "MODIFIED")
+	for _, target := range targets {
+		if target.File == nil {
+			fmt.Println(color.RedString("%-40s  %-33s  %10s  %v", target.Entry, "-", "-", target.Err))
+			continue
+		}
+		size := utils.HumanReadableSize(uint64(target.File.Size))
+		if gdrive.IsFolder(target.File) {
+			size = "folder"
+		}
+		fmt.Printf("%-40s  %-33s  %10s  %s\n", target.File.Name, target.File.Id, size, formatListTime(target.File.ModifiedTime))
+	}
+}
+
+// printDriveCounts prints how many of the resolved targets are in My Drive and
+// in each Shared Drive, so a query that reaches further than intended is
+// noticed before the confirmation.
+func printDriveCounts(driveSvc *drive.Service, targets []deleteTarget) {
+	counts := map[string]int{}
+	for _, target := range targets {
+		if target.File != nil {
+			counts[target.File.DriveId]++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+	names := map[string]string{"": "My Drive or shared with the account"}
+	if len(counts) > 1 || counts[""] == 0 {
+		if drives, err := gdrive.ListSharedDrives(driveSvc); err == nil {
+			for _, shared := range drives {
+				names[shared.Id] = "Shared Drive " + shared.Name
+			}
+		}
+	}
+	driveIDs := make([]string, 0, len(counts))
+	for driveID := range counts {
+		driveIDs = append(driveIDs, driveID)
+	}
+	sort.Strings(driveIDs) // My Drive ("") first
+	for _, driveID := range driveIDs {
+		name, ok := names[driveID]
+		if !ok {
+			name = "Shared Drive " + driveID
+		}
+		fmt.Printf("%6d in %s\n", counts[driveID], name)
+	}
+}
@@ -0,0 +1,195 @@
+// File: penguindex-go/internal/commands/download.go
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"github.com/fatih/color"
+)
+
+// downloadItem is one remote file and where it should land locally.
+type downloadItem struct {
+	File      *drive.File
+	LocalPath string
+}
+
+// HandleDownload downloads a file, or a whole folder tree, by ID or link.
+// outPath is the destination file or directory; empty means the Drive name in
+// the current directory. Files that already exist locally with a matching
+// MD5 are skipped, so an interrupted folder download can simply be re-run.
+func HandleDownload(driveSvc *drive.Service, fileIDOrLink, outPath string) error {
+	infoColor := color.New(color.FgCyan).SprintfFunc()
+	successColor := color.New(color.FgGreen).SprintfFunc()
+	errorColor := color.New(color.FgRed).SprintfFunc()
+
+	fileID, err := gdrive.ExtractFileID(fileIDOrLink)
+	if err != nil {
+		return fmt.Errorf("invalid file ID or link: %w", err)
+	}
+	root, err := gdrive.GetFileMetadata(driveSvc, fileID)
+	if err != nil {
+		return err
+	}
+
+	destPath := outPath
+	if destPath == "" {
+		destPath = localFileName(root.Name)
+	} else if info, err := os.Stat(destPath); err == nil && info.IsDir() {
+		destPath = filepath.Join(destPath, localFileName(root.Name))
+	}
+
+	if !gdrive.IsFolder(root) {
+		utils.Status(infoColor("Downloading %s (%s) to %s", root.Name, utils.HumanReadableSize(uint64(root.Size)), destPath))
+		skipped, err := downloadOne(driveSvc, downloadItem{File: root, LocalPath: destPath})
+		if err != nil {
+			return err
+		}
+		if skipped {
+			utils.Status(successColor("%s already exists with a matching MD5, nothing to do.", destPath))
+			return nil
+		}
+		utils.Status(successColor("Downloaded %s, md5 verified.", destPath))
+		return nil
+	}
+
+	utils.Status(infoColor("Listing folder %s (%s)...", root.Name, root.Id))
+	items, native, failures := collectDownloads(driveSvc, root.Id, destPath, map[string]bool{root.Id: true})
+	if err := os.MkdirAll(destPath, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", destPath, err)
+	}
+
+	var totalSize int64
+	for _, item := range items {
+		totalSize += item.File.Size
+	}
+	utils.Status(infoColor("Downloading %d files (%s) into %s", len(items), utils.HumanReadableSize(uint64(totalSize)), destPath))
+
+	// Google Docs editor files have no binary content; they are skipped so
+	// the rest of the folder still downloads.
+	for _, item := range native {
+		utils.Warn(color.YellowString("SKIPPED %s (Google Docs editor file %s, export it from Drive instead)", item.LocalPath, item.File.MimeType))
+	}
+
+	downloaded, skipped := 0, len(native)
+	for _, item := range items {
+		if err := os.MkdirAll(filepath.Dir(item.LocalPath), 0o755); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", item.LocalPath, err))
+			continue
+		}
+		wasSkipped, err := downloadOne(driveSvc, item)
+		switch {
+		case err != nil:
+			fmt.Println(errorColor("FAILED  %s: %v", item.LocalPath, err))
+			failures = append(failures, fmt.Errorf("%s: %w", item.LocalPath, err))
+		case wasSkipped:
+			skipped++
+			fmt.Println(color.YellowString("SKIPPED %s (already downloaded)", item.LocalPath))
+		default:
+			downloaded++
+		}
+	}
+
+	fmt.Printf("Downloaded %d, skipped %d, failed %d.\n", downloaded, skipped, len(failures))
+	if len(failures) > 0 {
+		for _, failure := range failures {
+			fmt.Println(errorColor("  %v", failure))
+		}
+		return fmt.Errorf("%d entries failed to download", len(failures))
+	}
+	return nil
+}
+
+// collectDownloads walks a Drive folder tree and maps every file to a path
+// below localDir. Google Docs editor files are returned separately in native
+// because they cannot be downloaded. Listing errors are collected so one
+// unreadable subfolder does not stop the rest of the tree.
+func collectDownloads(driveSvc *drive.Service, folderID, localDir string, visited map[string]bool) (items, native []downloadItem, failures []error) {
+	files, err := gdrive.ListFolder(driveSvc, folderID)
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+	// Sort so duplicate names get the same local suffix on every run.
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].Name != files[j].Name {
+			return files[i].Name < files[j].Name
+		}
+		if files[i].CreatedTime != files[j].CreatedTime {
+			return files[i].CreatedTime < files[j].CreatedTime
+		}
+		return files[i].Id < files[j].Id
+	})
+
+	used := map[string]bool{}
+	for _, file := range files {
+		localPath := filepath.Join(localDir, uniqueLocalName(localFileName(file.Name), used))
+		if gdrive.IsFolder(file) {
+			if visited[file.Id] {
+				continue
+			}
+			visited[file.Id] = true
+			subItems, subNative, subFailures := collectDownloads(driveSvc, file.Id, localPath, visited)
+			items = append(items, subItems...)
+			native = append(native, subNative...)
+			failures = append(failures, subFailures...)
+			continue
+		}
+		if gdrive.IsGoogleAppsFile(file) {
+			native = append(native, downloadItem{File: file, LocalPath: localPath})
+			continue
+		}
+		items = append(items, downloadItem{File: file, LocalPath: localPath})
+	}
+	return items, native, failures
+}
+
+// downloadOne downloads a single file unless an identical copy is already at
+// its local path, in which case it reports skipped.
+func downloadOne(driveSvc *drive.Service, item downloadItem) (skipped bool, err error) {
+	info, err := os.Stat(item.LocalPath)
+	switch {
+	case err == nil && info.IsDir():
+		return false, fmt.Errorf("%s is a directory", item.LocalPath)
+	case err == nil && info.Size() == item.File.Size && item.File.Md5Checksum != "":
+		localMD5, err := gdrive.LocalFileMD5(item.LocalPath)
+		if err != nil {
+			return false, err
+		}
+		if strings.EqualFold(localMD5, item.File.Md5Checksum) {
+			return true, nil
+		}
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return false, fmt.Errorf("failed to inspect %s: %w", item.LocalPath, err)
+	}
+	return false, gdrive.DownloadFile(driveSvc, item.File, item.LocalPath)
+}
+
+// localFileName makes a Drive name safe to use as a single path component.
+func localFileName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_", "\x00", "_").Replace(name)
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
+
+// uniqueLocalName returns name, or "name (1).ext", "name (2).ext", ... if an
+// earlier sibling already took it. Drive allows duplicate names; disks do not.
+func uniqueLocalName(name string, used map[string]bool) string {
+	candidate := name
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
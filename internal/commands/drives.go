@@ -0,0 +1,75 @@
+// File: penguindex-go/internal/commands/drives.go
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"google.golang.org/api/drive/v3"
+	"github.com/fatih/color"
+)
+
+// driveEntry is the -json output schema of drives ls.
+type driveEntry struct {
+	Name        string `json:"name"`
+	ID          string `json:"id"`
+	Access      string `json:"access"` // read-only, upload or manage
+	Hidden      bool   `json:"hidden"`
+	CreatedTime string `json:"createdTime"`
+}
+
+// HandleDrivesList prints the Shared Drives the service account can access.
+// Their IDs can be used anywhere a folder ID is accepted, including aliases.
+func HandleDrivesList(driveSvc *drive.Service, jsonOutput bool) error {
+	drives, err := gdrive.ListSharedDrives(driveSvc)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(drives, func(i, j int) bool { return strings.ToLower(drives[i].Name) < strings.ToLower(drives[j].Name) })
+
+	if jsonOutput {
+		entries := []driveEntry{}
+		for _, shared := range drives {
+			entries = append(entries, driveEntry{
+				Name:        shared.Name,
+				ID:          shared.Id,
+				Access:      driveAccess(shared),
+				Hidden:      shared.Hidden,
+				CreatedTime: shared.CreatedTime,
+			})
+		}
+		return printJSON(entries)
+	}
+	if len(drives) == 0 {
+		fmt.Println("The service account is not a member of any Shared Drive.")
+		return nil
+	}
+
+	fmt.Printf("%-32s  %-19s  %-10s  %s\n", "NAME", "ID", "ACCESS", "CREATED")
+	for _, shared := range drives {
+		access := color.GreenString("%-10s", driveAccess(shared))
+		if driveAccess(shared) == "read-only" {
+			access = color.YellowString("%-10s", "read-only")
+		}
+		name := shared.Name
+		if shared.Hidden {
+			name += " (hidden)"
+		}
+		fmt.Printf("%-32s  %-19s  %s  %s\n", name, shared.Id, access, formatListTime(shared.CreatedTime))
+	}
+	fmt.Println(color.CyanString("%d Shared Drives. Use an ID with -folder, ls or folders add.", len(drives)))
+	return nil
+}
+
+// driveAccess summarises what the service account may do in a Shared Drive.
+func driveAccess(shared *drive.Drive) string {
+	switch {
+	case shared.Capabilities == nil || !shared.Capabilities.CanAddChildren:
+		return "read-only"
+	case shared.Capabilities.CanDeleteChildren:
+		return "manage"
+	}
+	return "upload"
+}
@@ -0,0 +1,142 @@
+// File: penguindex-go/internal/commands/folders.go
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jendermine/penguindex-go/internal/config"
+	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"github.com/fatih/color"
+)
+
+// aliasNameRegex keeps aliases shorter than any file or folder ID (25+
+// characters), so an alias can never shadow a raw folder ID passed to -folder.
+// Shared Drive root IDs are shorter, but always resolve by their alias if any.
+var aliasNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,23}$`)
+
+// FolderTarget is a Drive folder resolved from an alias, link or raw ID,
+// optionally with a sub-path below it (see -path).
+type FolderTarget struct {
+	ID    string
+	Alias string // Alias the root folder is known by, or "" if it has none
+	Path  string // Slash-separated sub-path below the root, created on demand
+}
+
+// Label is the folder segment used in direct download links and notifications:
+// the alias if there is one, otherwise the raw folder ID, followed by the sub-path.
+func (t FolderTarget) Label() string {
+	label := t.ID
+	if t.Alias != "" {
+		label = t.Alias
+	}
+	if components, err := gdrive.SplitFolderPath(t.Path); err == nil && len(components) > 0 {
+		label += "/" + strings.Join(components, "/")
+	}
+	return label
+}
+
+// ResolveFolder turns an alias, Drive folder link or raw folder ID into a
+// FolderTarget. An empty value resolves to the configured default folder.
+func ResolveFolder(appCfg *config.AppConfig, value string) (FolderTarget, error) {
+	if value == "" {
+		value = appCfg.DefaultFolderID
+	}
+	if id, ok := appCfg.FolderAliases[value]; ok {
+		return FolderTarget{ID: id, Alias: value}, nil
+	}
+	id, err := gdrive.ExtractFileID(value)
+	if err != nil {
+		return FolderTarget{}, fmt.Errorf("'%s' is neither a folder alias nor a Drive folder ID or link", value)
+	}
+	// A raw ID still gets its alias label if one points at it.
+	for alias, aliasID := range appCfg.FolderAliases {
+		if aliasID == id {
+			return FolderTarget{ID: id, Alias: alias}, nil
+		}
+	}
+	return FolderTarget{ID: id}, nil
+}
+
+// HandleFoldersList prints every alias of the active profile and checks that
+// each folder exists and is writable by the service account.
+func HandleFoldersList(driveSvc *drive.Service, settings *config.Settings) error {
+	successColor := color.New(color.FgGreen).SprintfFunc()
+	errorColor := color.New(color.FgRed).SprintfFunc()
+
+	aliases := settings.FolderAliases()
+	if len(aliases) == 0 {
+		fmt.Printf("No folder aliases in profile %s. Add one with: folders add <alias> <folder-id>\n", settings.Profile.Value)
+		return nil
+	}
+
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	var broken int
+	for _, alias := range names {
+		folder, err := gdrive.CheckFolderWritable(driveSvc, aliases[alias])
+		if err != nil {
+			broken++
+			fmt.Printf("%-24s %s  %s\n", alias, aliases[alias], errorColor("ERROR: %v", err))
+			continue
+		}
+		fmt.Printf("%-24s %s  %s\n", alias, aliases[alias], successColor("OK (%s)", folder.Name))
+	}
+	if broken > 0 {
+		return fmt.Errorf("%d of %d folder aliases are not usable", broken, len(names))
+	}
+	return nil
+}
+
+// HandleFoldersAdd validates the folder and stores alias -> folder ID in the active profile.
+func HandleFoldersAdd(driveSvc *drive.Service, settings *config.Settings, alias, folderIDOrLink string) error {
+	successColor := color.New(color.FgGreen).SprintfFunc()
+
+	if !aliasNameRegex.MatchString(alias) {
+		return fmt.Errorf("invalid alias '%s': use 1-24 letters, digits, '.', '_' or '-'", alias)
+	}
+	folderID, err := gdrive.ExtractFileID(folderIDOrLink)
+	if err != nil {
+		return fmt.Errorf("invalid folder ID or link: %w", err)
+	}
+	folder, err := gdrive.CheckFolderWritable(driveSvc, folderID)
+	if err != nil {
+		return err
+	}
+
+	profile := settings.ActiveProfile()
+	if profile.Folders == nil {
+		profile.Folders = map[string]string{}
+	}
+	if previous, ok := profile.Folders[alias]; ok && previous != folderID {
+		utils.Warn(color.YellowString("Replacing alias %s (was %s)", alias, previous))
+	}
+	profile.Folders[alias] = folderID
+	if err := settings.Save(); err != nil {
+		return err
+	}
+	utils.Status(successColor("Alias %s -> %s (%s) saved to %s", alias, folderID, folder.Name, settings.ConfigPath))
+	return nil
+}
+
+// HandleFoldersRemove deletes an alias from the active profile. It needs no Drive access.
+func HandleFoldersRemove(settings *config.Settings, alias string) error {
+	profile := settings.ActiveProfile()
+	if _, ok := profile.Folders[alias]; !ok {
+		return fmt.Errorf("no alias '%s' in profile %s", alias, settings.Profile.Value)
+	}
+	delete(profile.Folders, alias)
+	if err := settings.Save(); err != nil {
+		return err
+	}
+	utils.Status(color.GreenString("Alias %s removed from %s", alias, settings.ConfigPath))
+	return nil
+}
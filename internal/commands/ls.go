@@ -0,0 +1,187 @@
+// File: penguindex-go/internal/commands/ls.go
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"github.com/fatih/color"
+)
+
+// Sort keys accepted by ls -sort.
+const (
+	SortByName     = "name"
+	SortBySize     = "size"
+	SortByModified = "modified"
+)
+
+// ListOptions controls how HandleList prints a folder.
+type ListOptions struct {
+	SortBy    string // name, size or modified
+	Reverse   bool
+	Long      bool // Add MD5, created time and link columns
+	Recursive bool // Descend into subfolders and print a tree
+	JSON      bool // Machine-readable output
+}
+
+// ValidateSortKey checks the ls -sort flag value.
+func ValidateSortKey(sortBy string) error {
+	switch sortBy {
+	case SortByName, SortBySize, SortByModified:
+		return nil
+	}
+	return fmt.Errorf("invalid sort key %q (want name, size or modified)", sortBy)
+}
+
+// listEntry is one listed item; it is also the -json output schema.
+type listEntry struct {
+	Name         string      `json:"name"`
+	ID           string      `json:"id"`
+	Size         int64       `json:"size"`
+	MimeType     string      `json:"mimeType"`
+	ModifiedTime string      `json:"modifiedTime"`
+	CreatedTime  string      `json:"createdTime,omitempty"`
+	MD5Checksum  string      `json:"md5Checksum,omitempty"`
+	WebViewLink  string      `json:"webViewLink,omitempty"`
+	IsFolder     bool        `json:"isFolder"`
+	Children     []listEntry `json:"children,omitempty"`
+}
+
+// HandleList prints the contents of a folder, optionally as a recursive tree or JSON.
+func HandleList(driveSvc *drive.Service, folder FolderTarget, opts ListOptions) error {
+	entries, err := collectListing(driveSvc, folder.ID, opts, map[string]bool{folder.ID: true})
+	if err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		if entries == nil {
+			entries = []listEntry{} // Print [] rather than null for empty folders
+		}
+		return printJSON(entries)
+	}
+
+	if !opts.Recursive {
+		fmt.Println(color.CyanString("Contents of %s (%s): %d items", folder.Label(), folder.ID, len(entries)))
+	} else {
+		fmt.Println(color.CyanString("%s (%s)", folder.Label(), folder.ID))
+	}
+	printListing(entries, opts, "")
+	return nil
+}
+
+// collectListing lists folderID and, when recursive, every subfolder below it.
+// visited guards against cycles, which Drive's multi-parent model allows.
+func collectListing(driveSvc *drive.Service, folderID string, opts ListOptions, visited map[string]bool) ([]listEntry, error) {
+	files, err := gdrive.ListFolder(driveSvc, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []listEntry
+	for _, file := range files {
+		entry := listEntry{
+			Name:         file.Name,
+			ID:           file.Id,
+			Size:         file.Size,
+			MimeType:     file.MimeType,
+			ModifiedTime: file.ModifiedTime,
+			CreatedTime:  file.CreatedTime,
+			MD5Checksum:  file.Md5Checksum,
+			WebViewLink:  file.WebViewLink,
+			IsFolder:     gdrive.IsFolder(file),
+		}
+		if opts.Recursive && entry.IsFolder && !visited[file.Id] {
+			visited[file.Id] = true
+			if entry.Children, err = collectListing(driveSvc, file.Id, opts, visited); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, entry)
+	}
+	sortListing(entries, opts)
+	return entries, nil
+}
+
+// sortListing orders folders first, then by the chosen key, with name as tie-breaker.
+func sortListing(entries []listEntry, opts ListOptions) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.IsFolder != b.IsFolder {
+			return a.IsFolder
+		}
+		if opts.Reverse {
+			a, b = b, a
+		}
+		switch opts.SortBy {
+		case SortBySize:
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		case SortByModified:
+			if a.ModifiedTime != b.ModifiedTime {
+				return a.ModifiedTime < b.ModifiedTime // RFC 3339 in UTC sorts lexically
+			}
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+}
+
+// printListing prints entries as table rows, indenting tree levels with prefix.
+func printListing(entries []listEntry, opts ListOptions, prefix string) {
+	folderColor := color.New(color.FgBlue, color.Bold).SprintFunc()
+	dimColor := color.New(color.FgHiBlack).SprintFunc()
+
+	for i, entry := range entries {
+		branch, childPrefix := "", ""
+		if opts.Recursive {
+			branch, childPrefix = "├── ", "│   "
+			if i == len(entries)-1 {
+				branch, childPrefix = "└── ", "    "
+			}
+		}
+
+		name := entry.Name
+		size := utils.HumanReadableSize(uint64(entry.Size))
+		if entry.IsFolder {
+			name = folderColor(name + "/")
+			size = "-"
+		}
+		fmt.Printf("%s%s%s  %s  %10s  %-28s  %s",
+			prefix, branch, name,
+			dimColor(entry.ID),
+			size,
+			entry.MimeType,
+			formatListTime(entry.ModifiedTime),
+		)
+		if opts.Long {
+			md5 := entry.MD5Checksum
+			if md5 == "" {
+				md5 = "-"
+			}
+			fmt.Printf("  created %s  md5 %s  %s", formatListTime(entry.CreatedTime), md5, entry.WebViewLink)
+		}
+		fmt.Println()
+
+		if len(entry.Children) > 0 {
+			printListing(entry.Children, opts, prefix+childPrefix)
+		}
+	}
+}
+
+// formatListTime renders an RFC 3339 timestamp in local time, or "-".
+func formatListTime(value string) string {
+	if value == "" {
+		return "-"
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return parsed.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,16 @@
+// File: penguindex-go/internal/commands/output.go
+package commands
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// printJSON writes v to stdout as indented JSON, the format of every -json
+// output. Listings of Drive items use the Drive API field names; local state
+// uses the snake_case names of the config file.
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,23 @@
+// File: penguindex-go/internal/commands/prompt.go
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// confirm asks a yes/no question on in; anything but "y" or "yes" is a no.
+// The question goes to stderr so it never mixes with output on stdout.
+func confirm(in io.Reader, question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(os.Stderr)
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
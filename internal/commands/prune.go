@@ -0,0 +1,288 @@
+// File: penguindex-go/internal/commands/prune.go
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/config"
+	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"github.com/jendermine/penguindex-go/internal/telegram"
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"github.com/fatih/color"
+)
+
+// PruneOptions controls HandlePrune.
+type PruneOptions struct {
+	DryRun    bool     // Only print the plan
+	Permanent bool     // Delete instead of moving to trash
+	AssumeYes bool     // Do not ask for confirmation
+	Jobs      int      // Parallel deletions
+	Folders   []string // Restrict the run to these rule keys (aliases or IDs); empty means all
+}
+
+// prunePlan is the set of files one retention rule removes from one folder.
+type prunePlan struct {
+	Key    string // Rule key from the config file
+	Folder FolderTarget
+	Files  []pruneCandidate
+}
+
+// pruneCandidate is a file selected for removal and the limit it exceeded.
+type pruneCandidate struct {
+	File   *drive.File
+	Reason string
+	Err    error // Set once the deletion has been attempted and failed
+}
+
+// HandlePrune applies the retention rules of the active profile. Each rule's
+// folder is listed (files directly inside it only), the files each rule
+// would remove are printed, and after confirmation they are trashed (or
+// deleted with Permanent) by up to Jobs workers. A summary is sent to Telegram.
+func HandlePrune(driveSvc *drive.Service, appCfg *config.AppConfig, opts PruneOptions) error {
+	infoColor := color.New(color.FgCyan).SprintfFunc()
+	successColor := color.New(color.FgGreen).SprintfFunc()
+
+	keys, err := pruneRuleKeys(appCfg, opts.Folders)
+	if err != nil {
+		return err
+	}
+	keys, folders, err := resolvePruneFolders(appCfg, keys)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var plans []*prunePlan
+	var fileCount int
+	var totalBytes int64
+	for i, key := range keys {
+		rule, folder := appCfg.Retention[key], folders[i]
+		utils.Status(infoColor("Listing %s (%s)...", folder.Label(), folder.ID))
+		files, err := gdrive.ListFolder(driveSvc, folder.ID)
+		if err != nil {
+			return err
+		}
+		candidates, err := selectPruneCandidates(files, rule, now)
+		if err != nil {
+			return fmt.Errorf("retention rule for %s: %w", key, err)
+		}
+		plan := &prunePlan{Key: key, Folder: folder, Files: candidates}
+		plans = append(plans, plan)
+		fileCount += len(candidates)
+		for _, candidate := range candidates {
+			totalBytes += candidate.File.Size
+		}
+	}
+
+	printPrunePlan(plans)
+	verb := "trashed"
+	if opts.Permanent {
+		verb = "deleted"
+	}
+	if fileCount == 0 {
+		fmt.Println(successColor("Nothing to prune."))
+		return nil
+	}
+	fmt.Printf("%d files (%s) would be %s.\n", fileCount, utils.HumanReadableSize(uint64(totalBytes)), verb)
+	if opts.DryRun {
+		fmt.Println(infoColor("Dry run: nothing was changed."))
+		return nil
+	}
+	if !opts.AssumeYes && !confirm(os.Stdin, fmt.Sprintf("Prune these %d files?", fileCount)) {
+		return fmt.Errorf("aborted")
+	}
+
+	runPrune(driveSvc, plans, opts)
+
+	var summaries []telegram.PruneFolderSummary
+	var failed int
+	var reclaimed int64
+	for _, plan := range plans {
+		summary := telegram.PruneFolderSummary{Folder: plan.Folder.Label()}
+		var folderBytes int64
+		for _, candidate := range plan.Files {
+			if candidate.Err != nil {
+				summary.Failed++
+				continue
+			}
+			summary.Removed++
+			folderBytes += candidate.File.Size
+		}
+		if summary.Removed == 0 && summary.Failed == 0 {
+			continue
+		}
+		summary.Size = utils.HumanReadableSize(uint64(folderBytes))
+		summaries = append(summaries, summary)
+		failed += summary.Failed
+		reclaimed += folderBytes
+	}
+	fmt.Printf("Pruned %d files (%s %s), failed %d.\n", fileCount-failed, utils.HumanReadableSize(uint64(reclaimed)), verb, failed)
+	if !opts.Permanent && reclaimed > 0 {
+		utils.Status(infoColor("Trashed files still count against storage until the trash is emptied (trash empty)."))
+	}
+
+	if appCfg.TelegramBotToken != "" && appCfg.TelegramChatID != "" {
+		if err := telegram.SendPruneNotification(appCfg.TelegramBotToken, appCfg.TelegramChatID, verb, utils.HumanReadableSize(uint64(reclaimed)), summaries); err != nil {
+			utils.Warn(color.YellowString("Warning: Failed to send Telegram notification: %v", err))
+		} else {
+			utils.Status(successColor("Telegram notification sent."))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files could not be %s", failed, fileCount, verb)
+	}
+	return nil
+}
+
+// pruneRuleKeys returns the rule keys to apply, sorted, checking that any
+// explicitly requested folder actually has a rule.
+func pruneRuleKeys(appCfg *config.AppConfig, only []string) ([]string, error) {
+	if len(appCfg.Retention) == 0 {
+		return nil, fmt.Errorf("no retention rules in the active profile; add a \"retention\" section to the config file")
+	}
+	if len(only) > 0 {
+		for _, key := range only {
+			if _, ok := appCfg.Retention[key]; !ok {
+				return nil, fmt.Errorf("no retention rule for '%s'", key)
+			}
+		}
+		return only, nil
+	}
+	keys := make([]string, 0, len(appCfg.Retention))
+	for key := range appCfg.Retention {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// resolvePruneFolders validates the rules of keys and resolves their folders.
+// A key naming a folder that an earlier key already covers, such as a
+// repeated alias or an alias and its raw ID, is dropped, so no folder is
+// listed or pruned twice. Two different rules for one folder are refused.
+func resolvePruneFolders(appCfg *config.AppConfig, keys []string) ([]string, []FolderTarget, error) {
+	var kept []string
+	var folders []FolderTarget
+	firstKey := map[string]string{} // folder ID -> key that covers it
+	for _, key := range keys {
+		rule := appCfg.Retention[key]
+		if err := rule.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("retention rule for %s: %w", key, err)
+		}
+		folder, err := ResolveFolder(appCfg, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("retention rule for %s: %w", key, err)
+		}
+		if earlier, ok := firstKey[folder.ID]; ok {
+			if *appCfg.Retention[earlier] != *rule {
+				return nil, nil, fmt.Errorf("retention rules for %s and %s both apply to folder %s; remove one of them", earlier, key, folder.ID)
+			}
+			continue
+		}
+		firstKey[folder.ID] = key
+		kept = append(kept, key)
+		folders = append(folders, folder)
+	}
+	return kept, folders, nil
+}
+
+// selectPruneCandidates applies rule to the files of one folder. Files are
+// ranked newest first by modified time; a file is selected as soon as it
+// falls outside any limit. Subfolders are never pruned.
+func selectPruneCandidates(files []*drive.File, rule *config.RetentionRule, now time.Time) ([]pruneCandidate, error) {
+	maxBytes, err := rule.MaxTotalBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var regular []*drive.File
+	for _, file := range files {
+		if !gdrive.IsFolder(file) {
+			regular = append(regular, file)
+		}
+	}
+	sort.SliceStable(regular, func(i, j int) bool { return regular[i].ModifiedTime > regular[j].ModifiedTime })
+
+	var candidates []pruneCandidate
+	var keptBytes int64
+	for i, file := range regular {
+		reason := ""
+		modified, parseErr := time.Parse(time.RFC3339, file.ModifiedTime)
+		switch {
+		case rule.KeepNewest > 0 && i >= rule.KeepNewest:
+			reason = fmt.Sprintf("beyond newest %d", rule.KeepNewest)
+		case rule.MaxAgeDays > 0 && parseErr == nil && now.Sub(modified) > time.Duration(rule.MaxAgeDays)*24*time.Hour:
+			reason = fmt.Sprintf("older than %d days", rule.MaxAgeDays)
+		case maxBytes > 0 && keptBytes+file.Size > maxBytes:
+			reason = fmt.Sprintf("over %s total", utils.HumanReadableSize(uint64(maxBytes)))
+		}
+		if reason == "" {
+			keptBytes += file.Size
+			continue
+		}
+		candidates = append(candidates, pruneCandidate{File: file, Reason: reason})
+	}
+	return candidates, nil
+}
+
+// printPrunePlan prints what each rule is going to remove.
+func printPrunePlan(plans []*prunePlan) {
+	for _, plan := range plans {
+		fmt.Println(color.CyanString("%s (%s): %d files to prune", plan.Folder.Label(), plan.Folder.ID, len(plan.Files)))
+		for _, candidate := range plan.Files {
+			fmt.Printf("  %-40s  %10s  %s  %s\n",
+				candidate.File.Name,
+				utils.HumanReadableSize(uint64(candidate.File.Size)),
+				formatListTime(candidate.File.ModifiedTime),
+				color.HiBlackString(candidate.Reason),
+			)
+		}
+	}
+}
+
+// runPrune deletes every candidate with up to opts.Jobs workers, recording
+// failures on the candidates and printing one line per file.
+func runPrune(driveSvc *drive.Service, plans []*prunePlan, opts PruneOptions) {
+	var queue []*pruneCandidate
+	for _, plan := range plans {
+		for i := range plan.Files {
+			queue = append(queue, &plan.Files[i])
+		}
+	}
+	jobs := max(1, min(opts.Jobs, len(queue)))
+
+	var printMu sync.Mutex
+	work := make(chan *pruneCandidate)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for candidate := range work {
+				if opts.Permanent {
+					candidate.Err = gdrive.DeleteDriveFile(driveSvc, candidate.File.Id)
+				} else {
+					_, candidate.Err = gdrive.TrashDriveFile(driveSvc, candidate.File.Id)
+				}
+				printMu.Lock()
+				if candidate.Err != nil {
+					fmt.Println(color.RedString("FAILED  %s (%s): %v", candidate.File.Name, candidate.File.Id, candidate.Err))
+				} else {
+					fmt.Println(color.GreenString("OK      %s (%s)", candidate.File.Name, candidate.File.Id))
+				}
+				printMu.Unlock()
+			}
+		}()
+	}
+	for _, candidate := range queue {
+		work <- candidate
+	}
+	close(work)
+	wg.Wait()
+}
@@ -0,0 +1,143 @@
+// File: penguindex-go/internal/commands/prune_test.go
+package commands
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/config"
+	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestSelectPruneCandidates(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	file := func(name string, ageDays int, size int64) *drive.File {
+		return &drive.File{Name: name, Size: size, ModifiedTime: now.AddDate(0, 0, -ageDays).Format(time.RFC3339)}
+	}
+	// Deliberately not in date order; selectPruneCandidates ranks them itself.
+	files := []*drive.File{
+		file("old.bin", 40, 4096),
+		file("new.bin", 1, 1024),
+		{Name: "archive", MimeType: gdrive.FolderMimeType, ModifiedTime: now.AddDate(-2, 0, 0).Format(time.RFC3339)},
+		file("ancient.bin", 400, 1024),
+		file("mid.bin", 10, 2048),
+	}
+
+	tests := []struct {
+		name    string
+		rule    config.RetentionRule
+		want    []string // Pruned names, newest first
+		reasons []string
+		wantErr bool
+	}{
+		{name: "no limits", rule: config.RetentionRule{}},
+		{
+			name:    "keep newest",
+			rule:    config.RetentionRule{KeepNewest: 2},
+			want:    []string{"old.bin", "ancient.bin"},
+			reasons: []string{"beyond newest 2", "beyond newest 2"},
+		},
+		{name: "keep more than exist", rule: config.RetentionRule{KeepNewest: 10}},
+		{
+			name:    "max age",
+			rule:    config.RetentionRule{MaxAgeDays: 30},
+			want:    []string{"old.bin", "ancient.bin"},
+			reasons: []string{"older than 30 days", "older than 30 days"},
+		},
+		{
+			name:    "max total size",
+			rule:    config.RetentionRule{MaxTotalSize: "3 KiB"},
+			want:    []string{"old.bin", "ancient.bin"},
+			reasons: []string{"over 3.0 KiB total", "over 3.0 KiB total"},
+		},
+		{
+			name:    "first exceeded limit is the reason",
+			rule:    config.RetentionRule{KeepNewest: 3, MaxAgeDays: 5},
+			want:    []string{"mid.bin", "old.bin", "ancient.bin"},
+			reasons: []string{"older than 5 days", "older than 5 days", "beyond newest 3"},
+		},
+		{name: "invalid size", rule: config.RetentionRule{MaxTotalSize: "lots"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := selectPruneCandidates(files, &tt.rule, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("selectPruneCandidates succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectPruneCandidates: %v", err)
+			}
+			var names, reasons []string
+			for _, candidate := range candidates {
+				names = append(names, candidate.File.Name)
+				reasons = append(reasons, candidate.Reason)
+			}
+			if !reflect.DeepEqual(names, tt.want) || !reflect.DeepEqual(reasons, tt.reasons) {
+				t.Errorf("pruned %q (%q), want %q (%q)", names, reasons, tt.want, tt.reasons)
+			}
+		})
+	}
+}
+
+func TestSelectPruneCandidatesKeepsUndatedFilesByAge(t *testing.T) {
+	now := time.Now()
+	files := []*drive.File{{Name: "undated.bin", Size: 1}}
+	candidates, err := selectPruneCandidates(files, &config.RetentionRule{MaxAgeDays: 1}, now)
+	if err != nil || len(candidates) != 0 {
+		t.Errorf("selectPruneCandidates = %v, %v; want no candidates", candidates, err)
+	}
+}
+
+func TestResolvePruneFolders(t *testing.T) {
+	const moviesID = "1AbCdEfGhIjKlMnOpQrStUvWxYz"
+	const showsID = "1ZyXwVuTsRqPoNmLkJiHgFeDcBa"
+	week := &config.RetentionRule{MaxAgeDays: 7}
+	appCfg := &config.AppConfig{
+		FolderAliases: map[string]string{"movies": moviesID, "shows": showsID, "films": moviesID},
+		Retention: map[string]*config.RetentionRule{
+			"movies": week,
+			moviesID: {MaxAgeDays: 7},
+			"shows":  {KeepNewest: 3},
+			"films":  {KeepNewest: 1},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		keys     []string
+		wantKeys []string
+		wantErr  bool
+	}{
+		{name: "distinct folders", keys: []string{"movies", "shows"}, wantKeys: []string{"movies", "shows"}},
+		{name: "repeated alias", keys: []string{"movies", "movies"}, wantKeys: []string{"movies"}},
+		{name: "alias and its raw ID", keys: []string{"movies", moviesID, "shows"}, wantKeys: []string{"movies", "shows"}},
+		{name: "conflicting rules for one folder", keys: []string{"movies", "films"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, folders, err := resolvePruneFolders(appCfg, tt.keys)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("keys = %v, want %v", keys, tt.wantKeys)
+			}
+			if len(folders) != len(keys) {
+				t.Fatalf("got %d folders for %d keys", len(folders), len(keys))
+			}
+			for i, folder := range folders {
+				if want := appCfg.FolderAliases[keys[i]]; folder.ID != want {
+					t.Errorf("folder for %s = %s, want %s", keys[i], folder.ID, want)
+				}
+			}
+		})
+	}
+}
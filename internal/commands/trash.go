@@ -0,0 +1,172 @@
+// File: penguindex-go/internal/commands/trash.go
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"github.com/fatih/color"
+)
+
+// HandleRestore takes a trashed file or folder out of the trash.
+func HandleRestore(driveSvc *drive.Service, fileIDOrLink string) error {
+	fileID, err := gdrive.ExtractFileID(fileIDOrLink)
+	if err != nil {
+		return fmt.Errorf("invalid file ID or link: %w", err)
+	}
+	restored, err := gdrive.RestoreDriveFile(driveSvc, fileID)
+	if err != nil {
+		return err
+	}
+	utils.Status(color.GreenString("Restored '%s' (%s) to folder %s", restored.Name, restored.Id, strings.Join(restored.Parents, ", ")))
+	return nil
+}
+
+// trashEntry is the -json output schema of trash ls.
+type trashEntry struct {
+	Name        string `json:"name"`
+	ID          string `json:"id"`
+	Size        int64  `json:"size"`
+	MimeType    string `json:"mimeType"`
+	TrashedTime string `json:"trashedTime"`
+	IsFolder    bool   `json:"isFolder"`
+	DriveID     string `json:"driveId,omitempty"` // Shared Drive the item is in, if any
+}
+
+// trashBin is one trash: the service account's own or a Shared Drive's.
+type trashBin struct {
+	DriveID string // Empty for the service account's own trash
+	Name    string
+	Files   []*drive.File
+}
+
+// listTrashBins lists the trash selected by scope: the service account's own
+// by default, one Shared Drive's with DriveID, or the account's and every
+// Shared Drive's with AllDrives.
+func listTrashBins(driveSvc *drive.Service, scope gdrive.SearchScope) ([]trashBin, error) {
+	var bins []trashBin
+	switch {
+	case scope.DriveID != "":
+		bins = []trashBin{{DriveID: scope.DriveID, Name: "Shared Drive " + scope.DriveID}}
+	case scope.AllDrives:
+		drives, err := gdrive.ListSharedDrives(driveSvc)
+		if err != nil {
+			return nil, err
+		}
+		bins = []trashBin{{Name: "My Drive"}}
+		for _, shared := range drives {
+			bins = append(bins, trashBin{DriveID: shared.Id, Name: "Shared Drive " + shared.Name})
+		}
+	default:
+		bins = []trashBin{{Name: "My Drive"}}
+	}
+	for i := range bins {
+		files, err := gdrive.ListTrash(driveSvc, bins[i].DriveID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", bins[i].Name, err)
+		}
+		sort.SliceStable(files, func(a, b int) bool { return files[a].TrashedTime > files[b].TrashedTime })
+		bins[i].Files = files
+	}
+	return bins, nil
+}
+
+// HandleTrashList prints the trash selected by scope, most recently trashed
+// first. Several trash bins are listed one after another under a heading.
+func HandleTrashList(driveSvc *drive.Service, scope gdrive.SearchScope, jsonOutput bool) error {
+	bins, err := listTrashBins(driveSvc, scope)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		entries := []trashEntry{}
+		for _, bin := range bins {
+			for _, file := range bin.Files {
+				entries = append(entries, trashEntry{
+					Name:        file.Name,
+					ID:          file.Id,
+					Size:        file.Size,
+					MimeType:    file.MimeType,
+					TrashedTime: file.TrashedTime,
+					IsFolder:    gdrive.IsFolder(file),
+					DriveID:     bin.DriveID,
+				})
+			}
+		}
+		return printJSON(entries)
+	}
+
+	var itemCount int
+	var totalSize int64
+	for _, bin := range bins {
+		if len(bins) > 1 || bin.DriveID != "" {
+			if len(bin.Files) == 0 {
+				continue
+			}
+			fmt.Println(color.CyanString("%s:", bin.Name))
+		}
+		for _, file := range bin.Files {
+			size := utils.HumanReadableSize(uint64(file.Size))
+			name := file.Name
+			if gdrive.IsFolder(file) {
+				size = "-"
+				name += "/"
+			}
+			itemCount++
+			totalSize += file.Size
+			fmt.Printf("%s  %s  %10s  trashed %s\n", name, color.HiBlackString(file.Id), size, formatListTime(file.TrashedTime))
+		}
+	}
+	if itemCount == 0 {
+		fmt.Println("Trash is empty.")
+		return nil
+	}
+	fmt.Println(color.CyanString("%d items in trash (%s in files)", itemCount, utils.HumanReadableSize(uint64(totalSize))))
+	return nil
+}
+
+// HandleTrashEmpty permanently deletes everything in the trash selected by
+// scope after confirmation, which assumeYes skips.
+func HandleTrashEmpty(driveSvc *drive.Service, scope gdrive.SearchScope, assumeYes bool) error {
+	bins, err := listTrashBins(driveSvc, scope)
+	if err != nil {
+		return err
+	}
+	var itemCount int
+	for _, bin := range bins {
+		itemCount += len(bin.Files)
+	}
+	if itemCount == 0 {
+		fmt.Println("Trash is already empty.")
+		return nil
+	}
+	if len(bins) > 1 {
+		for _, bin := range bins {
+			if len(bin.Files) > 0 {
+				fmt.Printf("%6d in %s\n", len(bin.Files), bin.Name)
+			}
+		}
+	}
+	if !assumeYes && !confirm(os.Stdin, fmt.Sprintf("Permanently delete %d items in trash? This cannot be undone.", itemCount)) {
+		return fmt.Errorf("aborted")
+	}
+	for _, bin := range bins {
+		if len(bin.Files) == 0 {
+			continue
+		}
+		if err := gdrive.EmptyTrash(driveSvc, bin.DriveID); err != nil {
+			return fmt.Errorf("%s: %w", bin.Name, err)
+		}
+		if len(bins) > 1 || bin.DriveID != "" {
+			utils.Status(color.GreenString("Emptied trash of %s (%d items).", bin.Name, len(bin.Files)))
+		}
+	}
+	utils.Status(color.GreenString("Trash emptied (%d items permanently deleted).", itemCount))
+	return nil
+}
@@ -2,7 +2,12 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -14,73 +19,142 @@ import (
 	"github.com/fatih/color"
 )
 
-// HandleUpload orchestrates the file upload process.
-func HandleUpload(driveSvc *drive.Service, appCfg *config.AppConfig, filePath, folderID string) error {
+// uploadDetails holds the display values derived from an uploaded Drive file.
+type uploadDetails struct {
+	GdriveLink  string
+	DDLLink     string
+	SizeStr     string
+	MD5         string // Verified checksum, or a note that it could not be verified
+	CreatedTime time.Time
+}
+
+// UploadOptions controls how HandleUpload processes its inputs.
+type UploadOptions struct {
+	Jobs             int  // Number of concurrent file uploads; values below 1 mean 1
+	DeleteOnMismatch bool // Delete the remote copy when its MD5 does not match the local file
+	IfExists         string // Name-conflict policy: "", skip, overwrite, rename or error
+	Compare          string // Content comparison for skipping identical files: none, size or md5
+}
+
+// HandleUpload orchestrates the upload process. Each path may be a file, a
+// directory (mirrored recursively, see mirrorDirectory) or a glob pattern.
+// A single file gets the detailed single-upload output; anything else is
+// uploaded through the worker pool and reported with one summary and one
+// aggregated Telegram notification.
+// httpClient must be the authenticated client driveSvc was built from; it is
+// used directly for resumable upload sessions.
+func HandleUpload(driveSvc *drive.Service, httpClient *http.Client, appCfg *config.AppConfig, paths []string, folder FolderTarget, opts UploadOptions) error {
 	infoColor := color.New(color.FgCyan).SprintfFunc()
-	successColor := color.New(color.FgGreen).SprintfFunc()
 
-	if folderID == "" {
-		folderID = appCfg.DefaultFolderID
-		fmt.Println(infoColor("No folder ID provided, using default: %s", folderID))
+	if folder.ID == "" {
+		var err error
+		if folder, err = ResolveFolder(appCfg, ""); err != nil {
+			return err
+		}
+		utils.Status(infoColor("No folder ID provided, using default: %s", folder.ID))
+	}
+	if folder.Path != "" {
+		utils.Status(infoColor("Resolving path %s under folder %s", folder.Path, folder.ID))
+		leafID, err := gdrive.EnsureFolderPath(driveSvc, folder.ID, folder.Path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path '%s': %w", folder.Path, err)
+		}
+		folder.ID = leafID
 	}
 
-	fmt.Println(infoColor("Starting upload for: %s to folder ID: %s", filePath, folderID))
-	uploadedFile, err := gdrive.UploadFile(driveSvc, filePath, folderID)
+	expandedPaths, err := expandUploadPaths(paths)
 	if err != nil {
-		return fmt.Errorf("upload failed: %w", err)
+		return err
 	}
-	fmt.Println(successColor("\n--- Upload Successful ---")) // Newline to ensure it's after progress bar
 
-	// --- Process and display results ---
-	gdriveLink := uploadedFile.WebViewLink
-	if gdriveLink == "" { // Fallback if WebViewLink is not populated for some reason
-		gdriveLink = fmt.Sprintf("https://drive.google.com/file/d/%s/view?usp=sharing", uploadedFile.Id)
+	if len(expandedPaths) == 1 {
+		fileInfo, err := os.Stat(expandedPaths[0])
+		if err != nil {
+			return fmt.Errorf("cannot access %s: %w", expandedPaths[0], err)
+		}
+		if !fileInfo.IsDir() {
+			return handleFileUpload(driveSvc, httpClient, appCfg, expandedPaths[0], folder, opts)
+		}
 	}
-	// webContentLink is often the direct download link for files stored natively.
-	// For Google Docs, Sheets, etc., it might be an export link.
-	// Your Rust DDL: https://drive.google.com/uc?export=download&id={FILE_ID}
-	ddlLink := fmt.Sprintf("https://drive.google.com/uc?export=download&id=%s", uploadedFile.Id)
+	return handleBatchUpload(driveSvc, httpClient, appCfg, expandedPaths, folder, opts)
+}
 
-	var createdTime time.Time
-	if uploadedFile.CreatedTime != "" {
-		createdTime, err = time.Parse(time.RFC3339, uploadedFile.CreatedTime)
+// expandUploadPaths expands glob patterns and checks that every path exists.
+func expandUploadPaths(paths []string) ([]string, error) {
+	var expanded []string
+	for _, path := range paths {
+		if !strings.ContainsAny(path, "*?[") {
+			expanded = append(expanded, path)
+			continue
+		}
+		matches, err := filepath.Glob(path)
 		if err != nil {
-			fmt.Printf("Warning: Could not parse file creation time '%s': %v\n", uploadedFile.CreatedTime, err)
+			return nil, fmt.Errorf("invalid glob pattern %s: %w", path, err)
 		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", path)
+		}
+		expanded = append(expanded, matches...)
+	}
+	if len(expanded) == 0 {
+		return nil, fmt.Errorf("no files to upload")
 	}
+	return expanded, nil
+}
 
-	fileSizeStr := utils.HumanReadableSize(uint64(uploadedFile.Size))
+// handleFileUpload uploads a single file, prints its details and sends a Telegram notification.
+func handleFileUpload(driveSvc *drive.Service, httpClient *http.Client, appCfg *config.AppConfig, filePath string, folder FolderTarget, opts UploadOptions) error {
+	infoColor := color.New(color.FgCyan).SprintfFunc()
+	successColor := color.New(color.FgGreen).SprintfFunc()
 
-	fmt.Printf("File Name: %s\n", successColor(uploadedFile.Name))
-	fmt.Printf("Size: %s\n", successColor(fileSizeStr))
-	fmt.Printf("MIME Type: %s\n", successColor(uploadedFile.MimeType))
-	if !createdTime.IsZero() {
-		fmt.Printf("Created: %s\n", successColor(createdTime.Format("2006-01-02 15:04:05 MST")))
+	decision, err := resolveExisting(driveSvc, uploadItem{Path: filePath, DisplayPath: filePath, FolderID: folder.ID}, opts, newNameReservations())
+	if err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+	if decision.Skip != nil {
+		fmt.Println(successColor("Skipped: %s", describeSkip(decision)))
+		fmt.Printf("Gdrive Link: %s\n", successColor(describeUploadedFile(appCfg, decision.Skip, folder).GdriveLink))
+		return nil
+	}
+	if decision.Options.ExistingFileID != "" {
+		utils.Status(infoColor("Overwriting existing file ID: %s", decision.Options.ExistingFileID))
+	} else if decision.Options.Name != "" {
+		utils.Status(infoColor("Name already taken, uploading as: %s", decision.Options.Name))
 	}
-	fmt.Printf("Gdrive Link: %s\n", successColor(gdriveLink))
-	fmt.Printf("DDL Link: %s\n", successColor(ddlLink))
 
+	utils.Status(infoColor("Starting upload for: %s to folder ID: %s", filePath, folder.ID))
+	uploadedFile, err := gdrive.UploadFile(httpClient, filePath, folder.ID, decision.Options)
+	if err != nil {
+		return fmt.Errorf("upload failed: %w", handleChecksumMismatch(driveSvc, err, opts.DeleteOnMismatch, decision.Options.ExistingFileID))
+	}
+	fmt.Println(successColor("\n--- Upload Successful ---")) // Newline to ensure it's after progress bar
 
-	// Fetch folder name if one parent exists
-	folderName := "N/A"
-	if len(uploadedFile.Parents) > 0 {
-		parentFolderID := uploadedFile.Parents[0]
-		parentFolder, err := driveSvc.Files.Get(parentFolderID).Fields("name").Do()
-		if err == nil {
-			folderName = parentFolder.Name
-		} else {
-			fmt.Printf("Warning: Could not fetch parent folder name for ID %s: %v\n", parentFolderID, err)
-		}
+	// --- Process and display results ---
+	details := describeUploadedFile(appCfg, uploadedFile, folder)
+
+	fmt.Printf("File Name: %s\n", successColor(uploadedFile.Name))
+	fmt.Printf("Size: %s\n", successColor(details.SizeStr))
+	fmt.Printf("MIME Type: %s\n", successColor(uploadedFile.MimeType))
+	fmt.Printf("MD5: %s\n", successColor(details.MD5))
+	if !details.CreatedTime.IsZero() {
+		fmt.Printf("Created: %s\n", successColor(details.CreatedTime.Format("2006-01-02 15:04:05 MST")))
 	}
-	fmt.Printf("Folder Name: %s\n", successColor(folderName))
+	fmt.Printf("Gdrive Link: %s\n", successColor(details.GdriveLink))
+	fmt.Printf("DDL Link: %s\n", successColor(details.DDLLink))
 
+	folderName := parentFolderName(driveSvc, uploadedFile)
+	fmt.Printf("Folder Name: %s\n", successColor(folderName))
+	if folder.Alias != "" {
+		fmt.Printf("Folder Alias: %s\n", successColor(folder.Alias))
+		folderName = folder.Label() // Notifications refer to aliased folders by alias
+	}
 
 	// Send Telegram Notification
 	if appCfg.TelegramBotToken != "" && appCfg.TelegramChatID != "" {
-		fmt.Println(infoColor("Sending Telegram notification..."))
+		utils.Status(infoColor("Sending Telegram notification..."))
 		var createdTimeStr string
-		if !createdTime.IsZero() {
-			createdTimeStr = createdTime.Format("02 Jan 06 15:04 MST")
+		if !details.CreatedTime.IsZero() {
+			createdTimeStr = details.CreatedTime.Format("02 Jan 06 15:04 MST")
 		} else {
 			createdTimeStr = "N/A"
 		}
@@ -90,20 +164,116 @@ func HandleUpload(driveSvc *drive.Service, appCfg *config.AppConfig, filePath, f
 			appCfg.TelegramChatID,
 			uploadedFile.Name,
 			folderName,
-			fileSizeStr,
+			details.SizeStr,
 			uploadedFile.MimeType,
+			details.MD5,
 			createdTimeStr,
-			gdriveLink,
-			ddlLink,
+			details.GdriveLink,
+			details.DDLLink,
 		)
 		if err != nil {
-			fmt.Printf(color.YellowString("Warning: Failed to send Telegram notification: %v\n"), err)
+			utils.Warn(color.YellowString("Warning: Failed to send Telegram notification: %v", err))
 		} else {
-			fmt.Println(successColor("Telegram notification sent successfully."))
+			utils.Status(successColor("Telegram notification sent successfully."))
 		}
 	} else {
-		fmt.Println(color.YellowString("Telegram bot token or chat ID not configured. Skipping notification."))
+		utils.Warn(color.YellowString("Telegram bot token or chat ID not configured. Skipping notification."))
 	}
 
 	return nil
 }
+
+// describeUploadedFile derives links, size and creation time for display.
+// With a DDL base URL configured, the direct link is <base>/<folder label>/<name>,
+// where the label is the folder's alias or ID.
+func describeUploadedFile(appCfg *config.AppConfig, uploadedFile *drive.File, folder FolderTarget) uploadDetails {
+	details := uploadDetails{
+		GdriveLink: uploadedFile.WebViewLink,
+		// webContentLink is often the direct download link for files stored natively.
+		// For Google Docs, Sheets, etc., it might be an export link.
+		// Your Rust DDL: https://drive.google.com/uc?export=download&id={FILE_ID}
+		DDLLink: fmt.Sprintf("https://drive.google.com/uc?export=download&id=%s", uploadedFile.Id),
+		SizeStr: utils.HumanReadableSize(uint64(uploadedFile.Size)),
+		MD5:     uploadedFile.Md5Checksum + " (verified)",
+	}
+	if uploadedFile.Md5Checksum == "" {
+		details.MD5 = "N/A (not verified)"
+	}
+	if appCfg.DDLBaseURL != "" {
+		segments := strings.Split(folder.Label(), "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		details.DDLLink = strings.TrimSuffix(appCfg.DDLBaseURL, "/") + "/" +
+			strings.Join(segments, "/") + "/" + url.PathEscape(uploadedFile.Name)
+	}
+	if details.GdriveLink == "" { // Fallback if WebViewLink is not populated for some reason
+		details.GdriveLink = fmt.Sprintf("https://drive.google.com/file/d/%s/view?usp=sharing", uploadedFile.Id)
+	}
+
+	if uploadedFile.CreatedTime != "" {
+		createdTime, err := time.Parse(time.RFC3339, uploadedFile.CreatedTime)
+		if err != nil {
+			utils.Warn(color.YellowString("Warning: Could not parse file creation time '%s': %v", uploadedFile.CreatedTime, err))
+		} else {
+			details.CreatedTime = createdTime
+		}
+	}
+	return details
+}
+
+// describeSkip explains why an upload was skipped.
+func describeSkip(decision existsDecision) string {
+	if decision.Duplicate != "" {
+		return fmt.Sprintf("%s is uploaded under the same name in this batch", decision.Duplicate)
+	}
+	if decision.Identical {
+		return fmt.Sprintf("identical file already exists as %s (%s)", decision.Skip.Name, decision.Skip.Id)
+	}
+	return fmt.Sprintf("file already exists as %s (%s)", decision.Skip.Name, decision.Skip.Id)
+}
+
+// handleChecksumMismatch reports a corrupt upload loudly and, if requested,
+// deletes the remote copy. A file overwritten in place (replacedID) is the
+// user's original file with its ID, links and revisions, so it is never
+// deleted; the user is pointed at its previous revision instead. Errors
+// other than a checksum mismatch pass through.
+func handleChecksumMismatch(driveSvc *drive.Service, err error, deleteCorrupt bool, replacedID string) error {
+	var mismatch *gdrive.ChecksumMismatchError
+	if !errors.As(err, &mismatch) {
+		return err
+	}
+	utils.Warn(color.RedString("INTEGRITY FAILURE: %v", mismatch))
+	if replacedID != "" {
+		if deleteCorrupt {
+			utils.Warn(color.YellowString("Not deleting %s: it is the existing file that was overwritten.", replacedID))
+		}
+		return fmt.Errorf("%w (existing file was overwritten; restore its previous version from Manage versions in Drive)", err)
+	}
+	if !deleteCorrupt {
+		return fmt.Errorf("%w (corrupt copy kept; use -delete-on-mismatch to remove it automatically)", err)
+	}
+	if delErr := gdrive.DeleteDriveFile(driveSvc, mismatch.FileID); delErr != nil {
+		return fmt.Errorf("%w (failed to delete corrupt copy: %v)", err, delErr)
+	}
+	utils.Warn(color.YellowString("Deleted corrupt remote copy %s.", mismatch.FileID))
+	return fmt.Errorf("%w (corrupt copy deleted)", err)
+}
+
+// parentFolderName fetches the name of the first parent folder, or "N/A".
+func parentFolderName(driveSvc *drive.Service, uploadedFile *drive.File) string {
+	if len(uploadedFile.Parents) == 0 {
+		return "N/A"
+	}
+	return folderNameByID(driveSvc, uploadedFile.Parents[0])
+}
+
+// folderNameByID looks up a folder's name, falling back to "N/A" with a warning.
+func folderNameByID(driveSvc *drive.Service, folderID string) string {
+	name, err := gdrive.FolderName(driveSvc, folderID)
+	if err != nil {
+		utils.Warn(color.YellowString("Warning: Could not fetch parent folder name for ID %s: %v", folderID, err))
+		return "N/A"
+	}
+	return name
+}
@@ -0,0 +1,253 @@
+// File: penguindex-go/internal/commands/upload_batch.go
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/jendermine/penguindex-go/internal/config"
+	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"github.com/jendermine/penguindex-go/internal/telegram"
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"github.com/fatih/color"
+)
+
+// uploadItem is one local file queued for upload into a Drive folder.
+type uploadItem struct {
+	Path        string // Local path to open
+	DisplayPath string // Path shown in summaries and notifications
+	FolderID    string
+}
+
+// uploadResult records the outcome of one file in a multi-file upload.
+type uploadResult struct {
+	LocalPath string // Display path of the local file
+	File      *drive.File
+	Skipped   string // Reason the upload was skipped by the -if-exists policy, if it was
+	Replaced  string // ID of the existing file overwritten in place, if any
+	Err       error
+}
+
+// handleBatchUpload uploads several files and/or directory trees through a
+// bounded worker pool, then prints a per-file summary and sends one
+// aggregated Telegram notification.
+func handleBatchUpload(driveSvc *drive.Service, httpClient *http.Client, appCfg *config.AppConfig, paths []string, folder FolderTarget, opts UploadOptions) error {
+	infoColor := color.New(color.FgCyan).SprintfFunc()
+	successColor := color.New(color.FgGreen).SprintfFunc()
+
+	var items []uploadItem
+	var results []uploadResult
+	notifyFolderID := folder.ID
+	var notifyFolderName string // Looked up from Drive below if the folder has no alias
+	if folder.Alias != "" {
+		notifyFolderName = folder.Label()
+	}
+	notifyTitle := "Files Uploaded"
+	for _, path := range paths {
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			results = append(results, uploadResult{LocalPath: path, Err: err})
+			continue
+		}
+		if !fileInfo.IsDir() {
+			items = append(items, uploadItem{Path: path, DisplayPath: path, FolderID: folder.ID})
+			continue
+		}
+		rootFolder, dirItems, failures, err := mirrorDirectory(driveSvc, path, folder.ID)
+		if err != nil {
+			results = append(results, uploadResult{LocalPath: path, Err: err})
+			continue
+		}
+		if len(paths) == 1 {
+			// A single directory is reported as the folder it was mirrored into.
+			notifyFolderID = rootFolder.Id
+			notifyFolderName = rootFolder.Name
+			notifyTitle = "Folder Uploaded"
+		}
+		items = append(items, dirItems...)
+		results = append(results, failures...)
+	}
+
+	for _, result := range uploadConcurrently(driveSvc, httpClient, items, opts) {
+		if result.Err != nil {
+			result.Err = handleChecksumMismatch(driveSvc, result.Err, opts.DeleteOnMismatch, result.Replaced)
+		}
+		results = append(results, result)
+	}
+	failed := printUploadSummary(results)
+
+	if appCfg.TelegramBotToken != "" && appCfg.TelegramChatID != "" {
+		utils.Status(infoColor("Sending Telegram notification..."))
+		if notifyFolderName == "" {
+			notifyFolderName = folderNameByID(driveSvc, notifyFolderID)
+		}
+		err := sendBatchNotification(appCfg, notifyTitle, notifyFolderName, gdrive.FolderLink(notifyFolderID), results)
+		if err != nil {
+			utils.Warn(color.YellowString("Warning: Failed to send Telegram notification: %v", err))
+		} else {
+			utils.Status(successColor("Telegram notification sent successfully."))
+		}
+	} else {
+		utils.Warn(color.YellowString("Telegram bot token or chat ID not configured. Skipping notification."))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d entries failed to upload", failed, len(results))
+	}
+	return nil
+}
+
+// uploadConcurrently uploads items with at most jobs transfers in flight.
+// Results are returned in the same order as items, and a failure in one
+// worker never stops the others. With more than one job, per-file bars are
+// replaced by status lines and a shared aggregate bar.
+func uploadConcurrently(driveSvc *drive.Service, httpClient *http.Client, items []uploadItem, opts UploadOptions) []uploadResult {
+	results := make([]uploadResult, len(items))
+	if len(items) == 0 {
+		return results
+	}
+	jobs := max(1, min(opts.Jobs, len(items)))
+
+	var uploadOpts gdrive.UploadOptions
+	if jobs > 1 {
+		var totalBytes int64
+		for _, item := range items {
+			if fileInfo, err := os.Stat(item.Path); err == nil {
+				totalBytes += fileInfo.Size()
+			}
+		}
+		utils.Status(color.CyanString("Uploading %d files with %d parallel jobs...", len(items), jobs))
+		uploadOpts.Progress = gdrive.NewMultiProgress(totalBytes, len(items))
+	}
+
+	names := newNameReservations()
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = uploadOne(driveSvc, httpClient, items[i], opts, names, uploadOpts)
+			}
+		}()
+	}
+	for i := range items {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	if uploadOpts.Progress != nil {
+		uploadOpts.Progress.Finish()
+	}
+	return results
+}
+
+// uploadOne applies the -if-exists policy to item and uploads it unless skipped.
+func uploadOne(driveSvc *drive.Service, httpClient *http.Client, item uploadItem, opts UploadOptions, names *nameReservations, uploadOpts gdrive.UploadOptions) uploadResult {
+	result := uploadResult{LocalPath: item.DisplayPath}
+	decision, err := resolveExisting(driveSvc, item, opts, names)
+	if err != nil {
+		result.Err = err
+		countAsProcessed(item, uploadOpts)
+		return result
+	}
+	if decision.skipped() {
+		result.File, result.Skipped = decision.Skip, describeSkip(decision)
+		countAsProcessed(item, uploadOpts)
+		if uploadOpts.Progress != nil {
+			uploadOpts.Progress.Println("  %s: skipped", item.DisplayPath)
+		}
+		return result
+	}
+	uploadOpts.Name = decision.Options.Name
+	uploadOpts.ExistingFileID = decision.Options.ExistingFileID
+	result.Replaced = decision.Options.ExistingFileID
+	result.File, result.Err = gdrive.UploadFile(httpClient, item.Path, item.FolderID, uploadOpts)
+	return result
+}
+
+// countAsProcessed adds a file that will not be uploaded to the aggregate bar
+// so it can complete. The bar's total was built from local sizes, so the local
+// size is used here too, even when a remote copy of another size was skipped.
+func countAsProcessed(item uploadItem, uploadOpts gdrive.UploadOptions) {
+	if uploadOpts.Progress == nil {
+		return
+	}
+	if fileInfo, err := os.Stat(item.Path); err == nil {
+		uploadOpts.Progress.Add(fileInfo.Size())
+	}
+}
+
+// printUploadSummary prints one line per result and returns the number of failures.
+func printUploadSummary(results []uploadResult) int {
+	successColor := color.New(color.FgGreen).SprintfFunc()
+	errorColor := color.New(color.FgRed).SprintfFunc()
+
+	fmt.Println(successColor("\n--- Upload Summary ---"))
+	var failed, skipped int
+	var totalBytes uint64
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("%s %s: %v\n", errorColor("FAILED"), result.LocalPath, result.Err)
+			continue
+		}
+		if result.Skipped != "" {
+			skipped++
+			fmt.Printf("%s %s: %s\n", color.YellowString("SKIPPED"), result.LocalPath, result.Skipped)
+			continue
+		}
+		totalBytes += uint64(result.File.Size)
+		fmt.Printf("%s %s (%s) -> %s md5:%s\n",
+			successColor("OK"),
+			result.LocalPath,
+			utils.HumanReadableSize(uint64(result.File.Size)),
+			result.File.Id,
+			md5OrNA(result.File),
+		)
+	}
+	fmt.Printf("Uploaded: %s, Skipped: %s, Failed: %s, Total Size: %s\n",
+		successColor("%d", len(results)-failed-skipped),
+		color.YellowString("%d", skipped),
+		errorColor("%d", failed),
+		successColor(utils.HumanReadableSize(totalBytes)),
+	)
+	return failed
+}
+
+// sendBatchNotification converts upload results into one aggregated Telegram message.
+func sendBatchNotification(appCfg *config.AppConfig, title, folderName, folderLink string, results []uploadResult) error {
+	entries := make([]telegram.BatchEntry, 0, len(results))
+	var totalBytes uint64
+	for _, result := range results {
+		entry := telegram.BatchEntry{Name: result.LocalPath, Size: "N/A", Failed: result.Err != nil, Skipped: result.Skipped != ""}
+		if result.File != nil && !entry.Skipped {
+			totalBytes += uint64(result.File.Size)
+			entry.Size = utils.HumanReadableSize(uint64(result.File.Size))
+			entry.MD5 = md5OrNA(result.File)
+		}
+		entries = append(entries, entry)
+	}
+	return telegram.SendBatchNotification(
+		appCfg.TelegramBotToken,
+		appCfg.TelegramChatID,
+		title,
+		folderName,
+		utils.HumanReadableSize(totalBytes),
+		folderLink,
+		entries,
+	)
+}
+
+// md5OrNA returns the file's verified MD5 checksum, or "N/A" if Drive has none.
+func md5OrNA(file *drive.File) string {
+	if file.Md5Checksum == "" {
+		return "N/A"
+	}
+	return file.Md5Checksum
+}
@@ -0,0 +1,97 @@
+// File: penguindex-go/internal/commands/upload_dir.go
+package commands
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"github.com/fatih/color"
+)
+
+// mirrorDirectory recreates the local directory tree rooted at dirPath as a
+// folder of the same name inside folderID. Folders that already exist are
+// reused, so re-running an interrupted directory upload does not duplicate
+// the tree. It returns one upload item per regular file, targeted at the
+// matching Drive folder, plus failure results for entries that could not be
+// read or mirrored. Failures are collected per entry so one bad subdirectory
+// does not stop the rest of the tree.
+func mirrorDirectory(driveSvc *drive.Service, dirPath, folderID string) (*drive.File, []uploadItem, []uploadResult, error) {
+	infoColor := color.New(color.FgCyan).SprintfFunc()
+	warnColor := color.New(color.FgYellow).SprintfFunc()
+
+	rootPath := filepath.Clean(dirPath)
+	rootName := filepath.Base(rootPath)
+	utils.Status(infoColor("Mirroring directory %s into folder ID: %s", rootPath, folderID))
+
+	// localDir -> Drive folder ID
+	folderIDs := map[string]string{}
+	var rootFolder *drive.File
+	var items []uploadItem
+	var failures []uploadResult
+
+	walkErr := filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
+		displayPath := filepath.ToSlash(filepath.Join(rootName, relativePath(rootPath, path)))
+		if err != nil {
+			if path == rootPath && rootFolder == nil {
+				return err // Nothing can be uploaded without the root folder.
+			}
+			// Record unreadable entries and keep walking the rest of the tree.
+			failures = append(failures, uploadResult{LocalPath: displayPath, Err: err})
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			parentID := folderID
+			name := rootName
+			if path != rootPath {
+				parentID = folderIDs[filepath.Dir(path)]
+				name = d.Name()
+			}
+			createdFolder, created, err := gdrive.FindOrCreateFolder(driveSvc, name, parentID)
+			if err != nil {
+				if path == rootPath {
+					return err // Nothing can be uploaded without the root folder.
+				}
+				failures = append(failures, uploadResult{LocalPath: displayPath, Err: err})
+				return fs.SkipDir
+			}
+			if path == rootPath {
+				rootFolder = createdFolder
+			}
+			folderIDs[path] = createdFolder.Id
+			if created {
+				utils.Status(infoColor("Created folder: %s (%s)", displayPath, createdFolder.Id))
+			} else {
+				utils.Status(infoColor("Using existing folder: %s (%s)", displayPath, createdFolder.Id))
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			utils.Warn(warnColor("Skipping non-regular file: %s", displayPath))
+			return nil
+		}
+		items = append(items, uploadItem{Path: path, DisplayPath: displayPath, FolderID: folderIDs[filepath.Dir(path)]})
+		return nil
+	})
+	if walkErr != nil {
+		return nil, nil, nil, fmt.Errorf("failed to mirror directory %s: %w", rootPath, walkErr)
+	}
+	return rootFolder, items, failures, nil
+}
+
+// relativePath returns path relative to root for display, falling back to path itself.
+func relativePath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
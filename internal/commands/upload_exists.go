@@ -0,0 +1,232 @@
+// File: penguindex-go/internal/commands/upload_exists.go
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jendermine/penguindex-go/internal/gdrive"
+	"google.golang.org/api/drive/v3"
+)
+
+// Policies for -if-exists, applied when the target folder already holds a
+// file with the same name. The empty policy keeps Drive's default behaviour
+// of creating a same-named duplicate without looking.
+const (
+	IfExistsSkip      = "skip"
+	IfExistsOverwrite = "overwrite"
+	IfExistsRename    = "rename"
+	IfExistsError     = "error"
+)
+
+// Comparisons for -compare. When set, an existing file that is identical by
+// the comparison is always skipped, whatever the -if-exists policy says.
+const (
+	CompareNone = "none"
+	CompareSize = "size"
+	CompareMD5  = "md5"
+)
+
+// ValidateIfExists checks the -if-exists and -compare flag values.
+func ValidateIfExists(policy, compare string) error {
+	switch policy {
+	case "", IfExistsSkip, IfExistsOverwrite, IfExistsRename, IfExistsError:
+	default:
+		return fmt.Errorf("invalid -if-exists policy %q (want skip, overwrite, rename or error)", policy)
+	}
+	switch compare {
+	case "", CompareNone, CompareSize, CompareMD5:
+	default:
+		return fmt.Errorf("invalid -compare mode %q (want none, size or md5)", compare)
+	}
+	if policy == "" && compare != "" && compare != CompareNone {
+		return fmt.Errorf("-compare requires an -if-exists policy")
+	}
+	return nil
+}
+
+// existsDecision is the outcome of checking an upload target for conflicts.
+type existsDecision struct {
+	Skip      *drive.File          // Existing file to report instead of uploading
+	Options   gdrive.UploadOptions // Name/ExistingFileID adjustments for the upload
+	Identical bool                 // Skip is because the content already matches
+	Duplicate string               // Skipped because this earlier item of the batch uploads under the same name
+}
+
+// skipped reports whether the item is not uploaded at all.
+func (d existsDecision) skipped() bool {
+	return d.Skip != nil || d.Duplicate != ""
+}
+
+// resolveExisting applies the -if-exists policy to one upload item, both
+// against files already in the target folder and against names earlier
+// items of the batch upload under. names records those; share one across a
+// batch.
+func resolveExisting(driveSvc *drive.Service, item uploadItem, opts UploadOptions, names *nameReservations) (existsDecision, error) {
+	var decision existsDecision
+	if opts.IfExists == "" {
+		return decision, nil
+	}
+
+	name := filepath.Base(item.Path)
+	existing, err := gdrive.FindFilesByName(driveSvc, item.FolderID, name)
+	if err != nil {
+		return decision, err
+	}
+
+	if len(existing) > 0 && opts.Compare != "" && opts.Compare != CompareNone {
+		match, err := findIdentical(item.Path, existing, opts.Compare)
+		if err != nil {
+			return decision, err
+		}
+		if match != nil {
+			decision.Skip, decision.Identical = match, true
+			return decision, nil
+		}
+	}
+
+	if len(existing) == 0 || opts.IfExists == IfExistsOverwrite {
+		// The item would upload under its own name: make sure no earlier
+		// item of the batch already does.
+		earlier := names.claim(item.FolderID, name, item.DisplayPath)
+		if earlier == "" && len(existing) == 0 {
+			return decision, nil
+		}
+		if earlier != "" {
+			return resolveDuplicate(driveSvc, item, opts, names, earlier)
+		}
+	}
+
+	switch opts.IfExists {
+	case IfExistsSkip:
+		decision.Skip = existing[0]
+	case IfExistsOverwrite:
+		if len(existing) > 1 {
+			return decision, fmt.Errorf("cannot overwrite '%s': %d files with that name exist in folder %s", name, len(existing), item.FolderID)
+		}
+		decision.Options.ExistingFileID = existing[0].Id
+	case IfExistsRename:
+		newName, err := names.reserve(driveSvc, item.FolderID, name, item.DisplayPath)
+		if err != nil {
+			return decision, err
+		}
+		decision.Options.Name = newName
+	case IfExistsError:
+		return decision, fmt.Errorf("'%s' already exists in folder %s (file ID %s)", name, item.FolderID, existing[0].Id)
+	}
+	return decision, nil
+}
+
+// resolveDuplicate applies the -if-exists policy to an item whose name an
+// earlier item of the batch already uploads under.
+func resolveDuplicate(driveSvc *drive.Service, item uploadItem, opts UploadOptions, names *nameReservations, earlier string) (existsDecision, error) {
+	var decision existsDecision
+	name := filepath.Base(item.Path)
+	switch opts.IfExists {
+	case IfExistsSkip:
+		decision.Duplicate = earlier
+	case IfExistsOverwrite:
+		return decision, fmt.Errorf("cannot overwrite '%s' in folder %s: %s is uploaded under the same name in this batch", name, item.FolderID, earlier)
+	case IfExistsRename:
+		newName, err := names.reserve(driveSvc, item.FolderID, name, item.DisplayPath)
+		if err != nil {
+			return decision, err
+		}
+		decision.Options.Name = newName
+	case IfExistsError:
+		return decision, fmt.Errorf("'%s' is already uploaded to folder %s from %s in this batch", name, item.FolderID, earlier)
+	}
+	return decision, nil
+}
+
+// findIdentical returns the first existing file matching the local file by size, and by MD5 if requested.
+func findIdentical(localPath string, existing []*drive.File, compare string) (*drive.File, error) {
+	fileInfo, err := os.Stat(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access %s: %w", localPath, err)
+	}
+	var localMD5 string
+	for _, file := range existing {
+		if file.Size != fileInfo.Size() {
+			continue
+		}
+		if compare == CompareSize {
+			return file, nil
+		}
+		if localMD5 == "" {
+			if localMD5, err = gdrive.LocalFileMD5(localPath); err != nil {
+				return nil, err
+			}
+		}
+		if strings.EqualFold(file.Md5Checksum, localMD5) {
+			return file, nil
+		}
+	}
+	return nil, nil
+}
+
+// nameReservations hands out free "name (n).ext" names for -if-exists rename
+// and records every name a batch uploads under, so two local files with the
+// same base name never end up as same-named files in one folder. Each folder
+// and base name is listed once, and parallel workers of a batch never upload
+// under the same name.
+type nameReservations struct {
+	mu      sync.Mutex
+	taken   map[string]map[string]bool // folderID + "/" + base name -> names in use or reserved
+	claimed map[string]string          // folderID + "/" + name -> display path of the batch item uploading under it
+}
+
+func newNameReservations() *nameReservations {
+	return &nameReservations{taken: map[string]map[string]bool{}, claimed: map[string]string{}}
+}
+
+// claim records that claimant uploads into folderID as name. If an earlier
+// item of the batch already does, nothing is recorded and that item's display
+// path is returned instead.
+func (r *nameReservations) claim(folderID, name, claimant string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := folderID + "/" + name
+	if earlier, ok := r.claimed[key]; ok {
+		return earlier
+	}
+	r.claimed[key] = claimant
+	return ""
+}
+
+// reserve returns the first "name (n).ext" that is neither in folderID nor
+// already used by the batch, and reserves it for claimant.
+func (r *nameReservations) reserve(driveSvc *drive.Service, folderID, name, claimant string) (string, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	// The lock is held across the listing so a second worker renaming the
+	// same file waits for it instead of listing the folder again.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := folderID + "/" + base
+	taken, ok := r.taken[key]
+	if !ok {
+		files, err := gdrive.FindFilesByNamePrefix(driveSvc, folderID, base+" (")
+		if err != nil {
+			return "", err
+		}
+		taken = map[string]bool{}
+		for _, file := range files {
+			taken[file.Name] = true
+		}
+		r.taken[key] = taken
+	}
+	for n := 1; n < 1000; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if _, claimed := r.claimed[folderID+"/"+candidate]; !taken[candidate] && !claimed {
+			taken[candidate] = true
+			r.claimed[folderID+"/"+candidate] = claimant
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("could not find a free name for '%s' in folder %s", name, folderID)
+}
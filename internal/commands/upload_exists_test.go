@@ -0,0 +1,207 @@
+// File: penguindex-go/internal/commands/upload_exists_test.go
+package commands
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+func TestValidateIfExists(t *testing.T) {
+	tests := []struct {
+		policy, compare string
+		wantErr         bool
+	}{
+		{"", "", false},
+		{"", CompareNone, false},
+		{IfExistsSkip, "", false},
+		{IfExistsSkip, CompareSize, false},
+		{IfExistsOverwrite, CompareMD5, false},
+		{IfExistsRename, CompareNone, false},
+		{IfExistsError, CompareMD5, false},
+		{"replace", "", true},
+		{"Skip", "", true},
+		{IfExistsSkip, "sha1", true},
+		{"", CompareSize, true},
+		{"", CompareMD5, true},
+	}
+	for _, tt := range tests {
+		err := ValidateIfExists(tt.policy, tt.compare)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateIfExists(%q, %q) = %v, want error: %v", tt.policy, tt.compare, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFindIdentical(t *testing.T) {
+	content := []byte("penguin backup\n")
+	localPath := filepath.Join(t.TempDir(), "backup.tar")
+	if err := os.WriteFile(localPath, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sum := md5.Sum(content)
+	localMD5 := hex.EncodeToString(sum[:])
+	size := int64(len(content))
+
+	sameSizeOther := &drive.File{Id: "other", Size: size, Md5Checksum: "00000000000000000000000000000000"}
+	identical := &drive.File{Id: "same", Size: size, Md5Checksum: localMD5}
+	differentSize := &drive.File{Id: "bigger", Size: size + 1, Md5Checksum: localMD5}
+
+	tests := []struct {
+		name     string
+		existing []*drive.File
+		compare  string
+		wantID   string
+	}{
+		{"size match", []*drive.File{differentSize, sameSizeOther}, CompareSize, "other"},
+		{"size mismatch", []*drive.File{differentSize}, CompareSize, ""},
+		{"md5 skips same-size other content", []*drive.File{sameSizeOther, identical}, CompareMD5, "same"},
+		{"md5 ignores checksum on different size", []*drive.File{differentSize}, CompareMD5, ""},
+		{"md5 is case-insensitive", []*drive.File{{Id: "upper", Size: size, Md5Checksum: fmt.Sprintf("%X", sum)}}, CompareMD5, "upper"},
+		{"md5 without remote checksum", []*drive.File{{Id: "native", Size: size}}, CompareMD5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, err := findIdentical(localPath, tt.existing, tt.compare)
+			if err != nil {
+				t.Fatalf("findIdentical: %v", err)
+			}
+			gotID := ""
+			if match != nil {
+				gotID = match.Id
+			}
+			if gotID != tt.wantID {
+				t.Errorf("findIdentical matched %q, want %q", gotID, tt.wantID)
+			}
+		})
+	}
+
+	if _, err := findIdentical(filepath.Join(t.TempDir(), "missing"), []*drive.File{identical}, CompareSize); err == nil {
+		t.Error("findIdentical succeeded for a missing local file")
+	}
+}
+
+func TestNameReservations(t *testing.T) {
+	// Pre-seeding the listing for a folder means reserve never calls Drive.
+	names := newNameReservations()
+	names.taken["folder/report"] = map[string]bool{"report (1).pdf": true, "report (3).pdf": true}
+	names.taken["folder/notes"] = map[string]bool{}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"report.pdf", "report (2).pdf"},
+		{"report.pdf", "report (4).pdf"},
+		{"report.csv", "report (1).csv"},
+		{"notes", "notes (1)"},
+		{"notes", "notes (2)"},
+	}
+	for _, tt := range tests {
+		got, err := names.reserve(nil, "folder", tt.name, "local/"+tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("reserve(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestNameReservationsAreUniqueAcrossWorkers(t *testing.T) {
+	names := newNameReservations()
+	names.taken["folder/clip"] = map[string]bool{"clip (1).mp4": true}
+
+	const workers = 20
+	got := make([]string, workers)
+	var wg sync.WaitGroup
+	for i := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i], _ = names.reserve(nil, "folder", "clip.mp4", fmt.Sprintf("dir%d/clip.mp4", i))
+		}()
+	}
+	wg.Wait()
+
+	seen := map[string]bool{"clip (1).mp4": true}
+	for _, name := range got {
+		if name == "" || seen[name] {
+			t.Fatalf("reserved names %q contain a duplicate or failure", got)
+		}
+		seen[name] = true
+	}
+}
+
+func TestNameReservationsClaim(t *testing.T) {
+	names := newNameReservations()
+	names.taken["folder/x"] = map[string]bool{}
+
+	if earlier := names.claim("folder", "x.bin", "a/x.bin"); earlier != "" {
+		t.Fatalf("first claim returned %q", earlier)
+	}
+	if earlier := names.claim("folder", "x.bin", "b/x.bin"); earlier != "a/x.bin" {
+		t.Errorf("second claim returned %q, want a/x.bin", earlier)
+	}
+	if earlier := names.claim("other", "x.bin", "b/x.bin"); earlier != "" {
+		t.Errorf("claim in another folder returned %q", earlier)
+	}
+	// A local file literally named like a rename target keeps it from being handed out.
+	names.claim("folder", "x (1).bin", "c/x (1).bin")
+	if got, err := names.reserve(nil, "folder", "x.bin", "b/x.bin"); err != nil || got != "x (2).bin" {
+		t.Errorf("reserve = %q, %v; want x (2).bin", got, err)
+	}
+	if earlier := names.claim("folder", "x (2).bin", "d/x (2).bin"); earlier != "b/x.bin" {
+		t.Errorf("claim of a reserved name returned %q, want b/x.bin", earlier)
+	}
+}
+
+func TestResolveExistingWithinBatch(t *testing.T) {
+	// An empty folder: every conflict comes from the batch itself.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"files":[]}`)
+	}))
+	defer server.Close()
+	driveSvc, err := drive.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := uploadItem{Path: "a/x.bin", DisplayPath: "a/x.bin", FolderID: "folder"}
+	second := uploadItem{Path: "b/x.bin", DisplayPath: "b/x.bin", FolderID: "folder"}
+
+	tests := []struct {
+		policy        string
+		wantDuplicate string
+		wantName      string
+		wantErr       bool
+	}{
+		{policy: IfExistsSkip, wantDuplicate: "a/x.bin"},
+		{policy: IfExistsRename, wantName: "x (1).bin"},
+		{policy: IfExistsOverwrite, wantErr: true},
+		{policy: IfExistsError, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			names := newNameReservations()
+			opts := UploadOptions{IfExists: tt.policy}
+			decision, err := resolveExisting(driveSvc, first, opts, names)
+			if err != nil || decision.skipped() || decision.Options.Name != "" {
+				t.Fatalf("first item: %+v, %v; want a plain upload", decision, err)
+			}
+			decision, err = resolveExisting(driveSvc, second, opts, names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("second item error = %v, want error: %v", err, tt.wantErr)
+			}
+			if decision.Duplicate != tt.wantDuplicate || decision.Options.Name != tt.wantName {
+				t.Errorf("second item = %+v, want duplicate %q, name %q", decision, tt.wantDuplicate, tt.wantName)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// File: penguindex-go/internal/config/bundle.go
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jendermine/penguindex-go/internal/utils"
+)
+
+// ServiceAccountInfo is the non-secret part of a service account key.
+type ServiceAccountInfo struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	ClientEmail  string `json:"client_email"`
+	ClientID     string `json:"client_id"`
+}
+
+// ParseServiceAccount checks that serviceAccountJSON is a service account
+// key and returns its identifying fields.
+func ParseServiceAccount(serviceAccountJSON string) (*ServiceAccountInfo, error) {
+	var key struct {
+		ServiceAccountInfo
+		PrivateKey string `json:"private_key"`
+	}
+	if err := json.Unmarshal([]byte(serviceAccountJSON), &key); err != nil {
+		return nil, fmt.Errorf("not valid JSON: %w", err)
+	}
+	if key.Type != "service_account" {
+		return nil, fmt.Errorf("type is %q, want \"service_account\"", key.Type)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, fmt.Errorf("client_email or private_key is missing")
+	}
+	return &key.ServiceAccountInfo, nil
+}
+
+// TelegramBotID returns the public bot ID part ("123456" of "123456:AA...")
+// of a bot token, or "" if the token is empty or malformed.
+func TelegramBotID(token string) string {
+	id, _, ok := strings.Cut(token, ":")
+	if !ok {
+		return ""
+	}
+	return id
+}
+
+// LoadEncryptedBundle reads a RemoteEncryptedBundle from an http(s) URL or
+// a local file. Only bundles fetched from a URL are checked against
+// verifier; local files are the user's own.
+func LoadEncryptedBundle(source string, verifier *Verifier) (*RemoteEncryptedBundle, error) {
+	if IsRemoteSource(source) {
+		return fetchBundleDocument(source, verifier, nil)
+	}
+	var remoteBundle RemoteEncryptedBundle
+	if err := utils.ReadJSONFile(source, &remoteBundle); err != nil {
+		return nil, fmt.Errorf("failed to read bundle file: %w", err)
+	}
+	if remoteBundle.EncryptedBundle == "" {
+		return nil, fmt.Errorf("%s has no encrypted_bundle field", source)
+	}
+	return &remoteBundle, nil
+}
+
+// IsRemoteSource reports whether a bundle source is an http(s) URL rather than a file.
+func IsRemoteSource(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// WriteEncryptedBundle writes remoteBundle as JSON to path, or to stdout if
+// path is "-".
+func WriteEncryptedBundle(path string, remoteBundle *RemoteEncryptedBundle) error {
+	return writeJSONDocument(path, remoteBundle)
+}
+
+// WriteSignedChatID writes a signed chat ID document to path, or to stdout
+// if path is "-".
+func WriteSignedChatID(path string, signed *SignedChatID) error {
+	return writeJSONDocument(path, signed)
+}
+
+func writeJSONDocument(path string, v any) error {
+	if path == "-" {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode document: %w", err)
+		}
+		_, err = fmt.Fprintln(os.Stdout, string(data))
+		return err
+	}
+	return utils.WriteJSONFile(path, v)
+}
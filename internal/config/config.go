@@ -4,21 +4,22 @@ package config
 import (
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/sha256" // Or crypto/sha512 if preferred for PBKDF2
+	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-
-	"golang.org/x/crypto/pbkdf2"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 const DEFAULT_TEST_FOLDER_ID = "1y1OzjZ5zrzX8rCNza6evRz68UHlSYuIW"
 
 // Crypto parameters for PBKDF2 and AES-GCM
 const (
-	PBKDF2_ITERATIONS = 310000
+	PBKDF2_ITERATIONS = 310000 // Legacy bundles, and -kdf pbkdf2
 
 	DERIVED_KEY_SIZE   uint32 = 32 // AES-256 key size
 	SALT_SIZE          = 16
@@ -26,80 +27,216 @@ const (
 )
 
 type RemoteEncryptedBundle struct {
-	EncryptedBundle string `json:"encrypted_bundle"` // hex encoded "salt + nonce + ciphertext"
+	EncryptedBundle string `json:"encrypted_bundle"` // hex encoded envelope, see envelope.go
+	SignedEnvelope         // Issue time and intended URL, covered by the signature
+	Signature       string `json:"signature,omitempty"` // base64 Ed25519 signature, see signing.go
 }
 
 type DecryptedBundle struct {
-	ServiceAccountJSONString string `json:"service_account_json_string"`
-	TelegramBotToken         string `json:"telegram_bot_token"`
+	ServiceAccountJSONString  string   `json:"service_account_json_string"`
+	ServiceAccountJSONStrings []string `json:"service_account_json_strings,omitempty"` // Extra accounts for the upload pool
+	TelegramBotToken          string   `json:"telegram_bot_token"`
+}
+
+// ServiceAccounts returns every service account JSON in the bundle, the
+// single-account field first.
+func (b *DecryptedBundle) ServiceAccounts() []string {
+	var accounts []string
+	if b.ServiceAccountJSONString != "" {
+		accounts = append(accounts, b.ServiceAccountJSONString)
+	}
+	for _, account := range b.ServiceAccountJSONStrings {
+		if account != "" {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
 }
 
 type AppConfig struct {
-	ServiceAccountJSON string
-	TelegramBotToken   string
-	TelegramChatID     string
-	DefaultFolderID    string
+	ServiceAccountJSONs []string // One or more accounts; see auth.Pool
+	TelegramBotToken    string
+	TelegramChatID      string
+	DefaultFolderID     string
+	DDLBaseURL          string                    // Optional index base for direct download links
+	FolderAliases       map[string]string         // alias -> Drive folder ID
+	Retention           map[string]*RetentionRule // folder alias or ID -> prune rule
 }
 
-type RemoteConfigDetails struct {
-	EncryptedBundleHex string
-	TelegramChatID     string
+// FetchEncryptedBundle downloads the encrypted bundle through cache, checks
+// it against verifier and returns its hex payload. cache may be nil.
+func FetchEncryptedBundle(bundleURL string, verifier *Verifier, cache *RemoteCache) (string, error) {
+	remoteBundle, err := fetchBundleDocument(bundleURL, verifier, cache)
+	if err != nil {
+		return "", err
+	}
+	return remoteBundle.EncryptedBundle, nil
 }
 
-func FetchRemoteConfigDetails(bundleURL, telegramChatIDURL string) (*RemoteConfigDetails, error) {
-	details := &RemoteConfigDetails{}
+// fetchBundleDocument is FetchEncryptedBundle returning the whole document.
+func fetchBundleDocument(bundleURL string, verifier *Verifier, cache *RemoteCache) (*RemoteEncryptedBundle, error) {
+	var remoteBundle RemoteEncryptedBundle
+	_, err := cache.fetch(bundleURL, "bundle", verifier.ordersReleases(), func(body []byte) (int64, error) {
+		remoteBundle = RemoteEncryptedBundle{}
+		if err := json.Unmarshal(body, &remoteBundle); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal encrypted bundle JSON from %s: %w", bundleURL, err)
+		}
+		issuedAt, err := verifier.verifyBundle(body, &remoteBundle, bundleURL)
+		if err != nil {
+			return 0, fmt.Errorf("refusing bundle from %s: %w", bundleURL, err)
+		}
+		return issuedAt, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &remoteBundle, nil
+}
 
-	respBundle, err := http.Get(bundleURL)
+// FetchTelegramChatID downloads the Telegram chat ID through cache, either
+// plain text or a SignedChatID document, and checks it against verifier.
+func FetchTelegramChatID(telegramChatIDURL string, verifier *Verifier, cache *RemoteCache) (string, error) {
+	var chatID string
+	_, err := cache.fetch(telegramChatIDURL, "Telegram chat ID", verifier.ordersReleases(), func(body []byte) (int64, error) {
+		verified, issuedAt, err := verifier.verifiedChatID(body, telegramChatIDURL)
+		if err != nil {
+			return 0, fmt.Errorf("refusing Telegram chat ID from %s: %w", telegramChatIDURL, err)
+		}
+		if chatID, err = NormalizeChatID(verified); err != nil {
+			return 0, fmt.Errorf("invalid Telegram chat ID from %s: %w", telegramChatIDURL, err)
+		}
+		return issuedAt, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get bundle URL %s: %w", bundleURL, err)
+		return "", err
+	}
+	return chatID, nil
+}
+
+// chatUsernamePattern matches public channel and group usernames.
+var chatUsernamePattern = regexp.MustCompile(`^@[A-Za-z][A-Za-z0-9_]{4,31}$`)
+
+// NormalizeChatID validates a Telegram chat ID, either a numeric ID such as
+// -1001234567890 or an @username, and returns it in canonical form without
+// surrounding whitespace or a byte order mark.
+func NormalizeChatID(chatID string) (string, error) {
+	chatID = strings.TrimSpace(strings.TrimPrefix(chatID, "\uFEFF"))
+	if strings.HasPrefix(chatID, "@") {
+		if !chatUsernamePattern.MatchString(chatID) {
+			return "", fmt.Errorf("%q is not a valid @username", chatID)
+		}
+		return chatID, nil
 	}
-	defer respBundle.Body.Close()
-	if respBundle.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad status from bundle URL %s: %s", bundleURL, respBundle.Status)
+	id, err := strconv.ParseInt(chatID, 10, 64)
+	if err != nil || id == 0 {
+		return "", fmt.Errorf("%q is neither a numeric chat ID nor an @username", chatID)
 	}
-	bodyBundleBytes, err := io.ReadAll(respBundle.Body)
+	return strconv.FormatInt(id, 10), nil
+}
+
+// RemoteConfig holds the two remote documents after verification.
+type RemoteConfig struct {
+	EncryptedBundle string // Hex payload; empty if the bundle was not fetched
+	TelegramChatID  string // Empty if the chat ID was not fetched
+}
+
+// FetchRemoteConfig fetches the bundle and the chat ID concurrently,
+// skipping either if its URL is empty. Both are verified before it returns,
+// so a failure in either is reported before any PIN prompt.
+func FetchRemoteConfig(bundleURL, telegramChatIDURL string, verifier *Verifier, cache *RemoteCache) (*RemoteConfig, error) {
+	remote := &RemoteConfig{}
+	var bundleErr, chatIDErr error
+	var wg sync.WaitGroup
+	if bundleURL != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			remote.EncryptedBundle, bundleErr = FetchEncryptedBundle(bundleURL, verifier, cache)
+		}()
+	}
+	if telegramChatIDURL != "" {
+		remote.TelegramChatID, chatIDErr = FetchTelegramChatID(telegramChatIDURL, verifier, cache)
+	}
+	wg.Wait()
+	if err := errors.Join(bundleErr, chatIDErr); err != nil {
+		return nil, err
+	}
+	return remote, nil
+}
+
+// DecryptBundle decrypts a hex-encoded bundle in either envelope version.
+// Use InspectBundle to find out whether it is a legacy bundle.
+func DecryptBundle(hexEncodedEncryptedBundle, pin string) (*DecryptedBundle, error) {
+	encryptedBundleBytes, err := decodeBundleHex(hexEncodedEncryptedBundle)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body from bundle URL %s: %w", bundleURL, err)
+		return nil, err
 	}
-	var remoteBundle RemoteEncryptedBundle
-	if err := json.Unmarshal(bodyBundleBytes, &remoteBundle); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal encrypted bundle JSON from %s: %w", bundleURL, err)
+	header, ciphertext, err := parseBundle(encryptedBundleBytes)
+	if err != nil {
+		return nil, err
 	}
-	details.EncryptedBundleHex = remoteBundle.EncryptedBundle
 
-	respChatID, err := http.Get(telegramChatIDURL)
+	aesgcm, err := newBundleCipher(header.KDF.deriveKey(pin, header.Salt))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get Telegram chat ID URL %s: %w", telegramChatIDURL, err)
+		return nil, err
 	}
-	defer respChatID.Body.Close()
-	if respChatID.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad status from Telegram chat ID URL %s: %s", telegramChatIDURL, respChatID.Status)
+
+	if len(header.Nonce) != aesgcm.NonceSize() {
+		return nil, fmt.Errorf("incorrect nonce size: expected %d, got %d", aesgcm.NonceSize(), len(header.Nonce))
 	}
-	bodyChatIDBytes, err := io.ReadAll(respChatID.Body)
+
+	decryptedData, err := aesgcm.Open(nil, header.Nonce, ciphertext, header.raw)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body from Telegram chat ID URL %s: %w", telegramChatIDURL, err)
+		// This error is common for incorrect PINs or corrupted data.
+		return nil, fmt.Errorf("failed to decrypt bundle (check PIN or bundle content - %s used): %w", header.KDF, err)
+	}
+	defer clear(decryptedData)
+
+	var bundle DecryptedBundle
+	if err := json.Unmarshal(decryptedData, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal decrypted bundle JSON: %w", err)
 	}
-	details.TelegramChatID = string(bodyChatIDBytes)
 
-	return details, nil
+	return &bundle, nil
 }
 
-func DecryptBundle(hexEncodedEncryptedBundle, pin string) (*DecryptedBundle, error) {
-	encryptedBundleBytes, err := hex.DecodeString(hexEncodedEncryptedBundle)
+// EncryptBundle is the inverse of DecryptBundle: it writes a current-version
+// envelope keyed with kdf and a fresh random salt, and returns it hex-encoded.
+func EncryptBundle(bundle *DecryptedBundle, pin string, kdf KDFParams) (string, error) {
+	if err := kdf.validate(); err != nil {
+		return "", err
+	}
+	plaintext, err := json.Marshal(bundle)
 	if err != nil {
-		return nil, fmt.Errorf("failed to hex decode encrypted bundle: %w", err)
+		return "", fmt.Errorf("failed to encode bundle JSON: %w", err)
 	}
+	defer clear(plaintext)
 
-	if len(encryptedBundleBytes) < SALT_SIZE+NONCE_SIZE_AES_GCM {
-		return nil, fmt.Errorf("encrypted data too short, expected salt + nonce + ciphertext, got %d bytes", len(encryptedBundleBytes))
+	header := &BundleHeader{
+		Version: BUNDLE_VERSION,
+		KDF:     kdf,
+		Salt:    make([]byte, SALT_SIZE),
+		Nonce:   make([]byte, NONCE_SIZE_AES_GCM),
+	}
+	if _, err := rand.Read(header.Salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	if _, err := rand.Read(header.Nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	salt := encryptedBundleBytes[:SALT_SIZE]
-	nonce := encryptedBundleBytes[SALT_SIZE : SALT_SIZE+NONCE_SIZE_AES_GCM]
-	ciphertext := encryptedBundleBytes[SALT_SIZE+NONCE_SIZE_AES_GCM:]
+	aesgcm, err := newBundleCipher(kdf.deriveKey(pin, header.Salt))
+	if err != nil {
+		return "", err
+	}
 
-	derivedKey := pbkdf2.Key([]byte(pin), salt, PBKDF2_ITERATIONS, int(DERIVED_KEY_SIZE), sha256.New)
+	headerBytes := header.encode()
+	encrypted := append(headerBytes, aesgcm.Seal(nil, header.Nonce, plaintext, headerBytes)...)
+	return hex.EncodeToString(encrypted), nil
+}
 
+// newBundleCipher returns AES-256-GCM keyed with derivedKey.
+func newBundleCipher(derivedKey []byte) (cipher.AEAD, error) {
 	block, err := aes.NewCipher(derivedKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
@@ -109,21 +246,13 @@ func DecryptBundle(hexEncodedEncryptedBundle, pin string) (*DecryptedBundle, err
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GCM: %w", err)
 	}
+	return aesgcm, nil
+}
 
-	if len(nonce) != aesgcm.NonceSize() {
-		return nil, fmt.Errorf("incorrect nonce size: expected %d, got %d", aesgcm.NonceSize(), len(nonce))
-	}
-
-	decryptedData, err := aesgcm.Open(nil, nonce, ciphertext, nil)
+func decodeBundleHex(hexEncodedEncryptedBundle string) ([]byte, error) {
+	encryptedBundleBytes, err := hex.DecodeString(hexEncodedEncryptedBundle)
 	if err != nil {
-		// This error is common for incorrect PINs or corrupted data.
-		return nil, fmt.Errorf("failed to decrypt bundle (check PIN or bundle content - PBKDF2 used): %w", err)
-	}
-
-	var bundle DecryptedBundle
-	if err := json.Unmarshal(decryptedData, &bundle); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal decrypted bundle JSON: %w", err)
+		return nil, fmt.Errorf("failed to hex decode encrypted bundle: %w", err)
 	}
-
-	return &bundle, nil
+	return encryptedBundleBytes, nil
 }
@@ -0,0 +1,45 @@
+// File: penguindex-go/internal/config/config_test.go
+package config
+
+import "testing"
+
+func TestNormalizeChatID(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "-1001234567890", want: "-1001234567890"},
+		{in: "123456789", want: "123456789"},
+		{in: "  -1001234567890\n", want: "-1001234567890"},
+		{in: "\uFEFF-1001234567890\r\n", want: "-1001234567890"},
+		{in: "+42", want: "42"},
+		{in: "007", want: "7"},
+		{in: "@my_channel", want: "@my_channel"},
+		{in: " @MyChannel2 ", want: "@MyChannel2"},
+		{in: "", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "-0", wantErr: true},
+		{in: "12 34", wantErr: true},
+		{in: "1e9", wantErr: true},
+		{in: "99999999999999999999", wantErr: true},
+		{in: "<html>Not Found</html>", wantErr: true},
+		{in: "@abc", wantErr: true},        // Too short
+		{in: "@1channel", wantErr: true},   // Must start with a letter
+		{in: "@my-channel", wantErr: true}, // Invalid character
+		{in: "@", wantErr: true},
+		{in: "@abcdefghijklmnopqrstuvwxyz0123456", wantErr: true}, // 33 characters
+	}
+	for _, tt := range tests {
+		got, err := NormalizeChatID(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NormalizeChatID(%q) = %q, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeChatID(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// File: penguindex-go/internal/config/envelope.go
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Bundle envelope, version 1. All integers are big-endian:
+//
+//	magic "PDXB" | version u8 | kdf u8 | time u32 | memory KiB u32 | threads u8 | salt | nonce | ciphertext
+//
+// Everything before the ciphertext is the header, which is authenticated as
+// GCM additional data, so the KDF parameters cannot be altered undetected.
+// Version 0 (legacy) bundles are just salt | nonce | ciphertext, keyed with
+// PBKDF2-SHA256 at PBKDF2_ITERATIONS and without additional data.
+const (
+	BUNDLE_MAGIC          = "PDXB"
+	BUNDLE_VERSION_LEGACY = 0
+	BUNDLE_VERSION        = 1
+)
+
+// KDF identifiers stored in the header.
+const (
+	KDF_PBKDF2_SHA256 uint8 = 1
+	KDF_ARGON2ID      uint8 = 2
+)
+
+const bundleHeaderSize = len(BUNDLE_MAGIC) + 1 + 1 + 4 + 4 + 1 + SALT_SIZE + NONCE_SIZE_AES_GCM
+
+// KDFParams selects the key derivation function and its cost.
+type KDFParams struct {
+	ID        uint8
+	Time      uint32 // Argon2id passes, or PBKDF2 iterations
+	MemoryKiB uint32 // Argon2id only
+	Threads   uint8  // Argon2id only
+}
+
+// DefaultKDFParams is used for new bundles: Argon2id as recommended by RFC 9106
+// for memory-constrained environments.
+var DefaultKDFParams = KDFParams{ID: KDF_ARGON2ID, Time: 3, MemoryKiB: 64 * 1024, Threads: 4}
+
+// PBKDF2KDFParams is the legacy KDF in a versioned envelope, for machines
+// that cannot spare the memory Argon2id needs.
+var PBKDF2KDFParams = KDFParams{ID: KDF_PBKDF2_SHA256, Time: PBKDF2_ITERATIONS}
+
+// KDFByName maps the -kdf flag values to parameters.
+func KDFByName(name string) (KDFParams, error) {
+	switch name {
+	case "argon2id":
+		return DefaultKDFParams, nil
+	case "pbkdf2":
+		return PBKDF2KDFParams, nil
+	default:
+		return KDFParams{}, fmt.Errorf("unknown KDF %q (want argon2id or pbkdf2)", name)
+	}
+}
+
+func (k KDFParams) String() string {
+	switch k.ID {
+	case KDF_PBKDF2_SHA256:
+		return fmt.Sprintf("PBKDF2-SHA256, %d iterations", k.Time)
+	case KDF_ARGON2ID:
+		return fmt.Sprintf("Argon2id, t=%d, m=%d MiB, p=%d", k.Time, k.MemoryKiB/1024, k.Threads)
+	default:
+		return fmt.Sprintf("unknown KDF %d", k.ID)
+	}
+}
+
+// validate rejects unknown KDFs and costs outside sane bounds, so a crafted
+// header can neither weaken a new bundle nor make decryption exhaust memory.
+func (k KDFParams) validate() error {
+	switch k.ID {
+	case KDF_PBKDF2_SHA256:
+		if k.Time < 100_000 || k.Time > 10_000_000 {
+			return fmt.Errorf("PBKDF2 iterations %d out of range", k.Time)
+		}
+	case KDF_ARGON2ID:
+		if k.Time < 1 || k.Time > 16 || k.MemoryKiB < 8*1024 || k.MemoryKiB > 1024*1024 || k.Threads < 1 || k.Threads > 64 {
+			return fmt.Errorf("Argon2id parameters out of range (%s)", k)
+		}
+	default:
+		return fmt.Errorf("unknown KDF %d", k.ID)
+	}
+	return nil
+}
+
+// deriveKey derives the AES-256 key from pin and salt.
+func (k KDFParams) deriveKey(pin string, salt []byte) []byte {
+	if k.ID == KDF_ARGON2ID {
+		return argon2.IDKey([]byte(pin), salt, k.Time, k.MemoryKiB, k.Threads, DERIVED_KEY_SIZE)
+	}
+	return pbkdf2.Key([]byte(pin), salt, int(k.Time), int(DERIVED_KEY_SIZE), sha256.New)
+}
+
+// BundleHeader is the parsed, non-secret part of an encrypted bundle.
+type BundleHeader struct {
+	Version int
+	KDF     KDFParams
+	Salt    []byte
+	Nonce   []byte
+	raw     []byte // Authenticated header bytes; nil for legacy bundles
+}
+
+// encode serialises a version 1 header.
+func (h *BundleHeader) encode() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(BUNDLE_MAGIC)
+	buf.WriteByte(BUNDLE_VERSION)
+	buf.WriteByte(h.KDF.ID)
+	_ = binary.Write(&buf, binary.BigEndian, h.KDF.Time)
+	_ = binary.Write(&buf, binary.BigEndian, h.KDF.MemoryKiB)
+	buf.WriteByte(h.KDF.Threads)
+	buf.Write(h.Salt)
+	buf.Write(h.Nonce)
+	return buf.Bytes()
+}
+
+// parseBundle splits raw bundle bytes into header and ciphertext. Data not
+// starting with BUNDLE_MAGIC is treated as a legacy bundle.
+func parseBundle(data []byte) (*BundleHeader, []byte, error) {
+	if !bytes.HasPrefix(data, []byte(BUNDLE_MAGIC)) {
+		if len(data) < SALT_SIZE+NONCE_SIZE_AES_GCM {
+			return nil, nil, fmt.Errorf("encrypted data too short, expected salt + nonce + ciphertext, got %d bytes", len(data))
+		}
+		header := &BundleHeader{
+			Version: BUNDLE_VERSION_LEGACY,
+			KDF:     PBKDF2KDFParams,
+			Salt:    data[:SALT_SIZE],
+			Nonce:   data[SALT_SIZE : SALT_SIZE+NONCE_SIZE_AES_GCM],
+		}
+		return header, data[SALT_SIZE+NONCE_SIZE_AES_GCM:], nil
+	}
+
+	if len(data) < bundleHeaderSize {
+		return nil, nil, fmt.Errorf("encrypted bundle header truncated: got %d bytes", len(data))
+	}
+	pos := len(BUNDLE_MAGIC)
+	header := &BundleHeader{Version: int(data[pos])}
+	if header.Version != BUNDLE_VERSION {
+		return nil, nil, fmt.Errorf("unsupported bundle version %d (this build reads up to %d)", header.Version, BUNDLE_VERSION)
+	}
+	header.KDF.ID = data[pos+1]
+	header.KDF.Time = binary.BigEndian.Uint32(data[pos+2:])
+	header.KDF.MemoryKiB = binary.BigEndian.Uint32(data[pos+6:])
+	header.KDF.Threads = data[pos+10]
+	pos += 11
+	header.Salt = data[pos : pos+SALT_SIZE]
+	header.Nonce = data[pos+SALT_SIZE : bundleHeaderSize]
+	header.raw = data[:bundleHeaderSize]
+	if err := header.KDF.validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid bundle header: %w", err)
+	}
+	return header, data[bundleHeaderSize:], nil
+}
+
+// InspectBundle returns the header of a hex-encoded bundle without decrypting it.
+func InspectBundle(hexEncodedEncryptedBundle string) (*BundleHeader, error) {
+	data, err := decodeBundleHex(hexEncodedEncryptedBundle)
+	if err != nil {
+		return nil, err
+	}
+	header, _, err := parseBundle(data)
+	return header, err
+}
@@ -0,0 +1,167 @@
+// File: penguindex-go/internal/config/envelope_test.go
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// testKDFs are the cheapest parameters validate accepts, to keep tests fast.
+var testKDFs = map[string]KDFParams{
+	"argon2id": {ID: KDF_ARGON2ID, Time: 1, MemoryKiB: 8 * 1024, Threads: 1},
+	"pbkdf2":   {ID: KDF_PBKDF2_SHA256, Time: 100_000},
+}
+
+var testBundle = &DecryptedBundle{
+	ServiceAccountJSONString:  `{"type":"service_account"}`,
+	ServiceAccountJSONStrings: []string{`{"type":"service_account","n":2}`},
+	TelegramBotToken:          "123456:AAtoken",
+}
+
+func TestEncryptDecryptBundleRoundTrip(t *testing.T) {
+	for name, kdf := range testKDFs {
+		t.Run(name, func(t *testing.T) {
+			encrypted, err := EncryptBundle(testBundle, "1234", kdf)
+			if err != nil {
+				t.Fatalf("EncryptBundle: %v", err)
+			}
+			header, err := InspectBundle(encrypted)
+			if err != nil {
+				t.Fatalf("InspectBundle: %v", err)
+			}
+			if header.Version != BUNDLE_VERSION || header.KDF != kdf {
+				t.Errorf("header = version %d, %s; want version %d, %s", header.Version, header.KDF, BUNDLE_VERSION, kdf)
+			}
+
+			decrypted, err := DecryptBundle(encrypted, "1234")
+			if err != nil {
+				t.Fatalf("DecryptBundle: %v", err)
+			}
+			if !sameBundle(decrypted, testBundle) {
+				t.Errorf("decrypted bundle = %+v, want %+v", decrypted, testBundle)
+			}
+			if _, err := DecryptBundle(encrypted, "4321"); err == nil {
+				t.Error("DecryptBundle with the wrong PIN succeeded")
+			}
+		})
+	}
+}
+
+func TestDecryptLegacyBundle(t *testing.T) {
+	plaintext, err := json.Marshal(testBundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt := make([]byte, SALT_SIZE)
+	nonce := make([]byte, NONCE_SIZE_AES_GCM)
+	_, _ = rand.Read(salt)
+	_, _ = rand.Read(nonce)
+	aesgcm, err := newBundleCipher(pbkdf2.Key([]byte("1234"), salt, PBKDF2_ITERATIONS, int(DERIVED_KEY_SIZE), sha256.New))
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := append(append(salt, nonce...), aesgcm.Seal(nil, nonce, plaintext, nil)...)
+	encrypted := hex.EncodeToString(legacy)
+
+	header, err := InspectBundle(encrypted)
+	if err != nil {
+		t.Fatalf("InspectBundle: %v", err)
+	}
+	if header.Version != BUNDLE_VERSION_LEGACY || header.KDF != PBKDF2KDFParams {
+		t.Errorf("header = version %d, %s; want the legacy format", header.Version, header.KDF)
+	}
+	decrypted, err := DecryptBundle(encrypted, "1234")
+	if err != nil {
+		t.Fatalf("DecryptBundle: %v", err)
+	}
+	if !sameBundle(decrypted, testBundle) {
+		t.Errorf("decrypted bundle = %+v, want %+v", decrypted, testBundle)
+	}
+}
+
+func TestDecryptBundleRejectsTamperedHeader(t *testing.T) {
+	encrypted, err := EncryptBundle(testBundle, "1234", testKDFs["argon2id"])
+	if err != nil {
+		t.Fatalf("EncryptBundle: %v", err)
+	}
+	data, _ := hex.DecodeString(encrypted)
+	timeOffset := len(BUNDLE_MAGIC) + 2
+
+	tests := []struct {
+		name   string
+		tamper func([]byte)
+	}{
+		// Still within the accepted range, so only GCM can notice.
+		{"KDF time", func(b []byte) { binary.BigEndian.PutUint32(b[timeOffset:], 2) }},
+		{"KDF threads", func(b []byte) { b[timeOffset+8] = 2 }},
+		{"salt", func(b []byte) { b[timeOffset+9] ^= 0xff }},
+		{"ciphertext", func(b []byte) { b[len(b)-1] ^= 0xff }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := append([]byte(nil), data...)
+			tt.tamper(tampered)
+			if _, err := DecryptBundle(hex.EncodeToString(tampered), "1234"); err == nil {
+				t.Error("DecryptBundle accepted a tampered bundle")
+			}
+		})
+	}
+}
+
+func TestParseBundleRejectsBadHeaders(t *testing.T) {
+	header := func(version, kdf uint8, time, memoryKiB uint32, threads uint8) []byte {
+		h := &BundleHeader{
+			KDF:   KDFParams{ID: kdf, Time: time, MemoryKiB: memoryKiB, Threads: threads},
+			Salt:  make([]byte, SALT_SIZE),
+			Nonce: make([]byte, NONCE_SIZE_AES_GCM),
+		}
+		data := h.encode()
+		data[len(BUNDLE_MAGIC)] = version
+		return append(data, make([]byte, 32)...)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"legacy too short", make([]byte, SALT_SIZE+NONCE_SIZE_AES_GCM-1), "too short"},
+		{"truncated header", []byte(BUNDLE_MAGIC + "\x01\x02"), "truncated"},
+		{"future version", header(2, KDF_ARGON2ID, 3, 64*1024, 4), "unsupported bundle version"},
+		{"unknown KDF", header(1, 9, 3, 64*1024, 4), "unknown KDF"},
+		{"PBKDF2 too few iterations", header(1, KDF_PBKDF2_SHA256, 1000, 0, 0), "out of range"},
+		{"PBKDF2 too many iterations", header(1, KDF_PBKDF2_SHA256, 20_000_000, 0, 0), "out of range"},
+		{"Argon2id zero time", header(1, KDF_ARGON2ID, 0, 64*1024, 4), "out of range"},
+		{"Argon2id too little memory", header(1, KDF_ARGON2ID, 3, 1024, 4), "out of range"},
+		{"Argon2id too much memory", header(1, KDF_ARGON2ID, 3, 4*1024*1024, 4), "out of range"},
+		{"Argon2id zero threads", header(1, KDF_ARGON2ID, 3, 64*1024, 0), "out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseBundle(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseBundle error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncryptBundleRejectsWeakKDF(t *testing.T) {
+	weak := KDFParams{ID: KDF_PBKDF2_SHA256, Time: 1000}
+	if _, err := EncryptBundle(testBundle, "1234", weak); err == nil {
+		t.Error("EncryptBundle accepted 1000 PBKDF2 iterations")
+	}
+}
+
+func sameBundle(a, b *DecryptedBundle) bool {
+	return a.ServiceAccountJSONString == b.ServiceAccountJSONString &&
+		a.TelegramBotToken == b.TelegramBotToken &&
+		strings.Join(a.ServiceAccountJSONStrings, "\n") == strings.Join(b.ServiceAccountJSONStrings, "\n")
+}
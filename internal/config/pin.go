@@ -0,0 +1,136 @@
+// File: penguindex-go/internal/config/pin.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// PINOptions are the non-interactive PIN sources given as global flags.
+type PINOptions struct {
+	File  string // -pin-file: file whose first line is the PIN
+	Stdin bool   // -pin-stdin: read the PIN from the first line of stdin
+}
+
+// ReadPIN returns the bundle PIN from the first source that is set, in this
+// order: the PENGUINDEX_PIN environment variable, -pin-file, -pin-stdin and
+// finally an interactive prompt on the terminal. source names the one used.
+func ReadPIN(opts PINOptions) (pin, source string, err error) {
+	switch {
+	case os.Getenv(ENV_PIN) != "":
+		source = "env " + ENV_PIN
+		pin = os.Getenv(ENV_PIN)
+	case opts.File != "":
+		source = "-pin-file " + opts.File
+		pin, err = readSecretFile(opts.File)
+	case opts.Stdin:
+		source = "-pin-stdin"
+		pin, err = readLine(os.Stdin)
+	default:
+		source = "terminal prompt"
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return "", source, fmt.Errorf("no PIN source: %s is not set, no -pin-file or -pin-stdin was given, and stdin is not a terminal", ENV_PIN)
+		}
+		pin, err = promptHidden("Enter PIN: ")
+	}
+	if err != nil {
+		return "", source, fmt.Errorf("failed to read PIN from %s: %w", source, err)
+	}
+	if pin == "" {
+		return "", source, fmt.Errorf("empty PIN from %s", source)
+	}
+	return pin, source, nil
+}
+
+// readSecretFile reads the first line of path, refusing files that users other
+// than the owner can read or write.
+func readSecretFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	// Windows has no Unix permission bits to check.
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("%s is accessible by other users (mode %04o); run chmod 600 %s", path, info.Mode().Perm(), path)
+	}
+	return readLine(file)
+}
+
+// readLine reads up to the first newline one byte at a time, so input after
+// the PIN is left on stdin for the command (e.g. "delete -").
+func readLine(r io.Reader) (string, error) {
+	var line strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line.WriteByte(buf[0])
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(line.String(), "\r"), nil
+}
+
+// ReadNewPIN returns the PIN to encrypt a bundle with, from PENGUINDEX_NEW_PIN,
+// newPINFile (same permission rules as -pin-file), or an interactive prompt
+// that asks twice.
+func ReadNewPIN(newPINFile string) (string, error) {
+	var pin, source string
+	var err error
+	switch {
+	case os.Getenv(ENV_NEW_PIN) != "":
+		source = "env " + ENV_NEW_PIN
+		pin = os.Getenv(ENV_NEW_PIN)
+	case newPINFile != "":
+		source = "-new-pin-file " + newPINFile
+		pin, err = readSecretFile(newPINFile)
+	default:
+		source = "terminal prompt"
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return "", fmt.Errorf("no new PIN source: %s is not set, no -new-pin-file was given, and stdin is not a terminal", ENV_NEW_PIN)
+		}
+		pin, err = promptHidden("New PIN: ")
+		if err == nil {
+			var again string
+			again, err = promptHidden("Repeat new PIN: ")
+			if err == nil && again != pin {
+				return "", fmt.Errorf("the two PINs do not match")
+			}
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read new PIN from %s: %w", source, err)
+	}
+	if pin == "" {
+		return "", fmt.Errorf("empty new PIN from %s", source)
+	}
+	return pin, nil
+}
+
+// promptHidden reads a line from the terminal without echoing it. The prompt
+// goes to stderr so it never mixes with a bundle or -json output on stdout.
+func promptHidden(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	value, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return string(value), err
+}
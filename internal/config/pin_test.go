@@ -0,0 +1,131 @@
+// File: penguindex-go/internal/config/pin_test.go
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// withStdin replaces os.Stdin with a file holding input for the rest of the test.
+func withStdin(t *testing.T, input string) *os.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdin
+	os.Stdin = file
+	t.Cleanup(func() {
+		os.Stdin = saved
+		file.Close()
+	})
+	return file
+}
+
+// writePINFile writes a PIN file with the given mode and returns its path.
+func writePINFile(t *testing.T, content string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pin")
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil { // Not subject to the umask
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadPINPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        string
+		file       bool
+		stdin      bool
+		want       string
+		wantSource string
+	}{
+		{name: "env over file and stdin", env: "env-pin", file: true, stdin: true, want: "env-pin", wantSource: "env " + ENV_PIN},
+		{name: "file over stdin", file: true, stdin: true, want: "file-pin", wantSource: "-pin-file "},
+		{name: "stdin", stdin: true, want: "stdin-pin", wantSource: "-pin-stdin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ENV_PIN, tt.env)
+			withStdin(t, "stdin-pin\n")
+			opts := PINOptions{Stdin: tt.stdin}
+			if tt.file {
+				opts.File = writePINFile(t, "file-pin\n", 0o600)
+			}
+			pin, source, err := ReadPIN(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pin != tt.want || !strings.HasPrefix(source, tt.wantSource) {
+				t.Errorf("ReadPIN = %q from %q, want %q from %s...", pin, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestReadSecretFileRefusesOpenPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permission bits on Windows")
+	}
+	path := writePINFile(t, "1234\n", 0o644)
+	_, err := readSecretFile(path)
+	if err == nil {
+		t.Fatal("readSecretFile accepted a 0644 file")
+	}
+	if !strings.Contains(err.Error(), path) || !strings.Contains(err.Error(), "0644") {
+		t.Errorf("error %q does not name the path and mode 0644", err)
+	}
+
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if pin, err := readSecretFile(path); err != nil || pin != "1234" {
+		t.Errorf("readSecretFile(0600) = %q, %v; want 1234", pin, err)
+	}
+}
+
+func TestReadLineLeavesRestOfStdin(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     string
+		wantRest string
+	}{
+		{input: "1234\nfile-a\nfile-b\n", want: "1234", wantRest: "file-a\nfile-b\n"},
+		{input: "1234\r\nrest", want: "1234", wantRest: "rest"},
+		{input: "1234", want: "1234"},
+		{input: "", want: ""},
+	}
+	for _, tt := range tests {
+		stdin := withStdin(t, tt.input)
+		got, err := readLine(stdin)
+		if err != nil || got != tt.want {
+			t.Errorf("readLine(%q) = %q, %v; want %q", tt.input, got, err, tt.want)
+		}
+		rest, err := io.ReadAll(stdin)
+		if err != nil || string(rest) != tt.wantRest {
+			t.Errorf("after readLine(%q), stdin holds %q, want %q", tt.input, rest, tt.wantRest)
+		}
+	}
+
+	// "delete -" reads its IDs from the same stdin as -pin-stdin.
+	t.Setenv(ENV_PIN, "")
+	stdin := withStdin(t, "1234\nfile-a\n")
+	if pin, _, err := ReadPIN(PINOptions{Stdin: true}); err != nil || pin != "1234" {
+		t.Fatalf("ReadPIN = %q, %v; want 1234", pin, err)
+	}
+	if rest, _ := io.ReadAll(stdin); string(rest) != "file-a\n" {
+		t.Errorf("after ReadPIN, stdin holds %q, want the IDs", rest)
+	}
+}
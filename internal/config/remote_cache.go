@@ -0,0 +1,224 @@
+// File: penguindex-go/internal/config/remote_cache.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/httpclient"
+	"github.com/jendermine/penguindex-go/internal/utils"
+)
+
+// REMOTE_CACHE_FILE keeps the last good copy of the bundle and chat ID
+// documents in the user cache directory. The bundle is stored encrypted,
+// exactly as served.
+const REMOTE_CACHE_FILE = "remote_config.json"
+
+// DEFAULT_CONFIG_MAX_STALENESS is how old a cached document may be and still
+// be used when its URL cannot be reached.
+const DEFAULT_CONFIG_MAX_STALENESS = "168h"
+
+// MAX_DOCUMENT_SIZE caps the bundle and chat ID responses. A bundle with a
+// few dozen service accounts stays well below it.
+const MAX_DOCUMENT_SIZE = 1 << 20
+
+// cachedDocument is one remote document with its HTTP validators.
+type cachedDocument struct {
+	Body         string    `json:"body"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ValidatedAt  time.Time `json:"validated_at"`        // Last time the server confirmed this copy
+	IssuedAt     int64     `json:"issued_at,omitempty"` // Signed issue time, see SignedEnvelope
+}
+
+// RemoteCache fetches remote documents through an on-disk cache. Copies are
+// revalidated with If-None-Match/If-Modified-Since, and when the server is
+// unreachable a copy validated within MaxStaleness is used instead, with a
+// warning through Logf. A nil RemoteCache always fetches without caching.
+type RemoteCache struct {
+	MaxStaleness time.Duration // 0 disables the fallback
+	Refresh      bool          // Fetch unconditionally and never fall back
+	Logf         func(string)
+
+	mu      sync.Mutex
+	entries map[string]*cachedDocument // URL -> document; loaded on first use
+}
+
+// fetchError is a failed fetch that the cache may paper over: the server
+// was unreachable or answered with a temporary error.
+type fetchError struct{ err error }
+
+func (e *fetchError) Error() string { return e.err.Error() }
+func (e *fetchError) Unwrap() error { return e.err }
+
+// fetch returns the document at url. check validates a body before it is
+// cached or used from the cache and returns its signed issue time; its errors
+// are never masked by the cache. If ordered is set (the documents are signed),
+// a document issued before the cached copy is refused, even with Refresh, so
+// a replaced document cannot be rolled back.
+func (c *RemoteCache) fetch(url, what string, ordered bool, check func([]byte) (int64, error)) ([]byte, error) {
+	if c == nil {
+		body, _, err := fetchDocument(url, what, nil)
+		if err != nil {
+			return nil, err
+		}
+		_, err = check(body)
+		return body, err
+	}
+
+	c.mu.Lock()
+	c.load()
+	cached := c.entries[url]
+	c.mu.Unlock()
+	validator := cached
+	if c.Refresh {
+		validator = nil
+	}
+
+	body, resp, err := fetchDocument(url, what, validator)
+	var temporary *fetchError
+	switch {
+	case errors.As(err, &temporary):
+		return c.fallback(what, cached, err, check)
+	case err != nil:
+		return nil, err
+	case resp.StatusCode == http.StatusNotModified:
+		body = []byte(cached.Body)
+	}
+	issuedAt, err := check(body)
+	if err != nil {
+		return nil, err
+	}
+	if ordered && cached != nil && issuedAt < cached.IssuedAt {
+		return nil, fmt.Errorf("refusing %s from %s: its signed issue time (%s) is older than that of the copy already seen (%s); an old document may be replayed",
+			what, url, formatIssuedAt(issuedAt), formatIssuedAt(cached.IssuedAt))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &cachedDocument{
+		Body:         string(body),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		ValidatedAt:  time.Now(),
+		IssuedAt:     issuedAt,
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		// A 304 may omit the validators; keep the ones that matched.
+		if entry.ETag == "" {
+			entry.ETag = cached.ETag
+		}
+		if entry.LastModified == "" {
+			entry.LastModified = cached.LastModified
+		}
+	}
+	c.entries[url] = entry
+	c.save()
+	return body, nil
+}
+
+// fallback answers a failed fetch from the cache if the copy is fresh enough.
+func (c *RemoteCache) fallback(what string, cached *cachedDocument, fetchErr error, check func([]byte) (int64, error)) ([]byte, error) {
+	if c.Refresh || cached == nil || c.MaxStaleness <= 0 {
+		return nil, fetchErr
+	}
+	age := time.Since(cached.ValidatedAt).Round(time.Second)
+	if age > c.MaxStaleness {
+		return nil, fmt.Errorf("%w (cached copy is %s old, more than the allowed %s)", fetchErr, age, c.MaxStaleness)
+	}
+	body := []byte(cached.Body)
+	if _, err := check(body); err != nil {
+		return nil, fmt.Errorf("cached %s is invalid: %w", what, err)
+	}
+	if c.Logf != nil {
+		c.Logf(fmt.Sprintf("Warning: %v; using the cached %s from %s ago.", fetchErr, what, age))
+	}
+	return body, nil
+}
+
+// formatIssuedAt renders a signed issue time for messages.
+func formatIssuedAt(issuedAt int64) string {
+	if issuedAt == 0 {
+		return "none"
+	}
+	return time.Unix(issuedAt, 0).Format(time.RFC3339)
+}
+
+// fetchDocument GETs url, conditionally if cached is set. A 304 response is
+// returned without a body. Network errors and 5xx/429 statuses come back as
+// *fetchError.
+func fetchDocument(url, what string, cached *cachedDocument) ([]byte, *http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid %s URL %s: %w", what, url, err)
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return nil, nil, &fetchError{fmt.Errorf("failed to get %s URL %s: %w", what, url, err)}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return nil, resp, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return nil, nil, &fetchError{fmt.Errorf("bad status from %s URL %s: %s", what, url, resp.Status)}
+	case resp.StatusCode != http.StatusOK:
+		return nil, nil, fmt.Errorf("bad status from %s URL %s: %s", what, url, resp.Status)
+	}
+	body, err := httpclient.ReadLimited(resp.Body, MAX_DOCUMENT_SIZE)
+	if errors.Is(err, httpclient.ErrTooLarge) {
+		return nil, nil, fmt.Errorf("%s URL %s: %w", what, url, err)
+	}
+	if err != nil {
+		return nil, nil, &fetchError{fmt.Errorf("failed to read response body from %s URL %s: %w", what, url, err)}
+	}
+	return body, resp, nil
+}
+
+// load reads the cache file once. A missing or unreadable cache is empty.
+// Callers hold c.mu.
+func (c *RemoteCache) load() {
+	if c.entries != nil {
+		return
+	}
+	c.entries = map[string]*cachedDocument{}
+	path, err := remoteCachePath()
+	if err != nil {
+		return
+	}
+	if err := utils.ReadJSONFile(path, &c.entries); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		c.entries = map[string]*cachedDocument{}
+	}
+}
+
+// save writes the cache file. Failures only cost the offline fallback, so
+// they are ignored. Callers hold c.mu.
+func (c *RemoteCache) save() {
+	path, err := remoteCachePath()
+	if err != nil {
+		return
+	}
+	_ = utils.WriteJSONFile(path, c.entries)
+}
+
+func remoteCachePath() (string, error) {
+	dir, err := utils.AppCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, REMOTE_CACHE_FILE), nil
+}
@@ -0,0 +1,184 @@
+// File: penguindex-go/internal/config/remote_cache_test.go
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/utils"
+)
+
+// issuedAtBody is a check function for test documents whose body is their
+// signed issue time.
+func issuedAtBody(body []byte) (int64, error) {
+	return strconv.ParseInt(string(body), 10, 64)
+}
+
+// useTempCache points the user cache directory at a temporary directory,
+// seeded with entries if any are given.
+func useTempCache(t *testing.T, entries map[string]*cachedDocument) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if entries == nil {
+		return
+	}
+	path, err := remoteCachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.WriteJSONFile(path, entries); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// cachedEntry returns the entry stored on disk for url.
+func cachedEntry(t *testing.T, url string) *cachedDocument {
+	t.Helper()
+	c := &RemoteCache{}
+	c.load()
+	return c.entries[url]
+}
+
+func TestRemoteCacheRevalidates(t *testing.T) {
+	useTempCache(t, nil)
+	const etag, lastModified = `"v1"`, "Mon, 02 Jan 2006 15:04:05 GMT"
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == lastModified {
+			conditional = append(conditional, r.URL.Path)
+			w.WriteHeader(http.StatusNotModified) // Without repeating the validators
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte("100"))
+	}))
+	defer server.Close()
+	url := server.URL + "/bundle.json"
+
+	for i := 0; i < 3; i++ {
+		body, err := (&RemoteCache{}).fetch(url, "bundle", true, issuedAtBody)
+		if err != nil || string(body) != "100" {
+			t.Fatalf("fetch %d = %q, %v; want 100", i+1, body, err)
+		}
+		entry := cachedEntry(t, url)
+		if entry == nil || entry.ETag != etag || entry.LastModified != lastModified || entry.IssuedAt != 100 {
+			t.Fatalf("after fetch %d, cached entry = %+v, want the validators and issue time kept", i+1, entry)
+		}
+	}
+	if len(conditional) != 2 {
+		t.Errorf("%d conditional requests were answered with 304, want 2", len(conditional))
+	}
+}
+
+func TestRemoteCacheRefreshSkipsValidators(t *testing.T) {
+	var validators []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		validators = append(validators, r.Header.Get("If-None-Match")+r.Header.Get("If-Modified-Since"))
+		w.Header().Set("ETag", `"v2"`)
+		w.Write([]byte("200"))
+	}))
+	defer server.Close()
+	url := server.URL + "/bundle.json"
+	useTempCache(t, map[string]*cachedDocument{
+		url: {Body: "100", ETag: `"v1"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT", ValidatedAt: time.Now(), IssuedAt: 100},
+	})
+
+	body, err := (&RemoteCache{Refresh: true}).fetch(url, "bundle", true, issuedAtBody)
+	if err != nil || string(body) != "200" {
+		t.Fatalf("fetch = %q, %v; want 200", body, err)
+	}
+	if len(validators) != 1 || validators[0] != "" {
+		t.Errorf("request validators = %q, want none", validators)
+	}
+	if entry := cachedEntry(t, url); entry.ETag != `"v2"` || entry.IssuedAt != 200 {
+		t.Errorf("cached entry = %+v, want the refreshed copy", entry)
+	}
+}
+
+func TestRemoteCacheFallback(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL + "/bundle.json"
+	server.Close() // Every fetch now fails with a network error
+
+	tests := []struct {
+		name         string
+		age          time.Duration
+		maxStaleness time.Duration
+		refresh      bool
+		wantErr      string // Empty if the cached copy is used
+	}{
+		{name: "within max staleness", age: time.Hour, maxStaleness: 2 * time.Hour},
+		{name: "past max staleness", age: 3 * time.Hour, maxStaleness: 2 * time.Hour, wantErr: "more than the allowed 2h0m0s"},
+		{name: "fallback disabled", age: time.Minute, wantErr: "failed to get bundle URL"},
+		{name: "refresh", age: time.Minute, maxStaleness: 2 * time.Hour, refresh: true, wantErr: "failed to get bundle URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempCache(t, map[string]*cachedDocument{
+				url: {Body: "100", ValidatedAt: time.Now().Add(-tt.age), IssuedAt: 100},
+			})
+			var warnings []string
+			cache := &RemoteCache{MaxStaleness: tt.maxStaleness, Refresh: tt.refresh}
+			cache.Logf = func(msg string) { warnings = append(warnings, msg) }
+
+			body, err := cache.fetch(url, "bundle", true, issuedAtBody)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("fetch = %q, %v; want an error containing %q", body, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(body) != "100" {
+				t.Fatalf("fetch = %q, %v; want the cached 100", body, err)
+			}
+			if len(warnings) != 1 || !strings.Contains(warnings[0], "using the cached bundle") {
+				t.Errorf("warnings = %q, want one about the cached bundle", warnings)
+			}
+		})
+	}
+}
+
+func TestRemoteCacheRefusesOlderIssuedAt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("100"))
+	}))
+	defer server.Close()
+	url := server.URL + "/bundle.json"
+
+	tests := []struct {
+		name    string
+		refresh bool
+		ordered bool
+		wantErr bool
+	}{
+		{name: "ordered", ordered: true, wantErr: true},
+		{name: "ordered with refresh", refresh: true, ordered: true, wantErr: true},
+		{name: "unordered", ordered: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempCache(t, map[string]*cachedDocument{
+				url: {Body: "200", ValidatedAt: time.Now(), IssuedAt: 200},
+			})
+			body, err := (&RemoteCache{Refresh: tt.refresh}).fetch(url, "bundle", tt.ordered, issuedAtBody)
+			if !tt.wantErr {
+				if err != nil || string(body) != "100" {
+					t.Errorf("fetch = %q, %v; want 100", body, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "older than that of the copy already seen") {
+				t.Fatalf("fetch = %q, %v; want a replay error", body, err)
+			}
+			if entry := cachedEntry(t, url); entry.Body != "200" || entry.IssuedAt != 200 {
+				t.Errorf("cached entry = %+v, want the newer copy kept", entry)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+// File: penguindex-go/internal/config/settings.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/httpclient"
+	"github.com/jendermine/penguindex-go/internal/utils"
+)
+
+// Compiled-in fallbacks, used when neither the environment nor the config
+// file provides a value.
+// !!! REPLACE THESE WITH YOUR ACTUAL VALUES !!!
+const EMBEDDED_BUNDLE_URL = "https://gist.githubusercontent.com/jendermine/f963de2bcf12c37421277d7702466b2b/raw/ceabd48a9f0f6412a1dd42af44f20b5619d04d6d/log.json"
+const TELEGRAM_CHAT_ID_URL = "https://gist.githubusercontent.com/jendermine/66015cce5cf15c0e04ba5987cb3ca342/raw/2e0f17aaee25abbcfa8a254f390bcb214775826b/log2.json"
+
+// Trust anchors for the two remote documents (see Verifier). Either the
+// base64 Ed25519 public key the documents are signed with, or the hex
+// SHA-256 digests of both documents exactly as served. Empty means unverified.
+const EMBEDDED_SIGNING_PUBLIC_KEY = ""
+const EMBEDDED_BUNDLE_SHA256 = ""
+const EMBEDDED_CHAT_ID_SHA256 = ""
+
+const (
+	CONFIG_FILE_NAME     = "config.json"
+	DEFAULT_PROFILE_NAME = "default"
+)
+
+// Environment variables. The per-setting variables override every profile.
+const (
+	ENV_CONFIG            = "PENGUINDEX_CONFIG"
+	ENV_PROFILE           = "PENGUINDEX_PROFILE"
+	ENV_BUNDLE_URL        = "PENGUINDEX_BUNDLE_URL"
+	ENV_CHAT_ID_URL       = "PENGUINDEX_CHAT_ID_URL"
+	ENV_DEFAULT_FOLDER_ID = "PENGUINDEX_DEFAULT_FOLDER_ID"
+	ENV_DDL_BASE_URL      = "PENGUINDEX_DDL_BASE_URL"
+	ENV_SIGNING_KEY       = "PENGUINDEX_SIGNING_PUBLIC_KEY"
+	ENV_BUNDLE_SHA256     = "PENGUINDEX_BUNDLE_SHA256"
+	ENV_CHAT_ID_SHA256    = "PENGUINDEX_CHAT_ID_SHA256"
+	ENV_MAX_STALENESS     = "PENGUINDEX_CONFIG_MAX_STALENESS"
+	ENV_PROXY             = "PENGUINDEX_PROXY" // Without it, HTTPS_PROXY/HTTP_PROXY/NO_PROXY apply
+	ENV_CA_FILE           = "PENGUINDEX_CA_FILE"
+	ENV_CONNECT_TIMEOUT   = "PENGUINDEX_CONNECT_TIMEOUT"
+	ENV_READ_TIMEOUT      = "PENGUINDEX_READ_TIMEOUT"
+	ENV_PIN               = "PENGUINDEX_PIN"     // Bundle PIN for non-interactive runs, see ReadPIN
+	ENV_NEW_PIN           = "PENGUINDEX_NEW_PIN" // PIN for bundle encrypt/rekey, see ReadNewPIN
+)
+
+// Profile is one named set of settings in the config file. Empty fields fall
+// back to the compiled-in constants.
+type Profile struct {
+	BundleURL       string `json:"bundle_url,omitempty"`
+	ChatIDURL       string `json:"chat_id_url,omitempty"`
+	DefaultFolderID string `json:"default_folder_id,omitempty"` // Folder ID or alias
+	// DDLBaseURL is the index base for direct download links, which are built
+	// as <base>/<folder alias or ID>/<file name>. Empty means Drive's own links.
+	DDLBaseURL string `json:"ddl_base_url,omitempty"`
+	// SigningPublicKey, or BundleSHA256 together with ChatIDSHA256, pin the
+	// remote documents so a swapped bundle or chat ID is rejected.
+	SigningPublicKey string `json:"signing_public_key,omitempty"`
+	BundleSHA256     string `json:"bundle_sha256,omitempty"`
+	ChatIDSHA256     string `json:"chat_id_sha256,omitempty"`
+	// ConfigMaxStaleness is how old the cached bundle and chat ID may be when
+	// their URLs are unreachable, as a Go duration (e.g. "72h"). "0" disables
+	// the offline fallback.
+	ConfigMaxStaleness string `json:"config_max_staleness,omitempty"`
+	// Network settings for every HTTP request: the config documents, Telegram
+	// and Drive. Timeouts are Go durations; see httpclient.Options.
+	Proxy          string `json:"proxy,omitempty"`
+	CAFile         string `json:"ca_file,omitempty"` // Extra PEM roots, e.g. for TLS interception
+	ConnectTimeout string `json:"connect_timeout,omitempty"`
+	ReadTimeout    string `json:"read_timeout,omitempty"`
+	// Folders maps short aliases (e.g. "movies") to Drive folder IDs.
+	Folders map[string]string `json:"folders,omitempty"`
+	// Retention maps a folder alias or ID to the rule the prune command applies to it.
+	Retention map[string]*RetentionRule `json:"retention,omitempty"`
+}
+
+// RetentionRule limits what a folder keeps. A file is pruned if any set limit
+// is exceeded; zero or empty fields are not applied.
+type RetentionRule struct {
+	MaxAgeDays   int    `json:"max_age_days,omitempty"`   // Prune files not modified for this many days
+	MaxTotalSize string `json:"max_total_size,omitempty"` // e.g. "50 GiB"; the oldest files go first
+	KeepNewest   int    `json:"keep_newest,omitempty"`    // Prune all but the newest N files
+}
+
+// MaxTotalBytes parses MaxTotalSize, returning 0 when it is unset.
+func (r *RetentionRule) MaxTotalBytes() (int64, error) {
+	if r.MaxTotalSize == "" {
+		return 0, nil
+	}
+	size, err := utils.ParseSize(r.MaxTotalSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max_total_size: %w", err)
+	}
+	return size, nil
+}
+
+// Validate checks that the rule sets at least one limit and that all are sane.
+func (r *RetentionRule) Validate() error {
+	if r == nil {
+		return fmt.Errorf("rule is empty")
+	}
+	if r.MaxAgeDays < 0 || r.KeepNewest < 0 {
+		return fmt.Errorf("max_age_days and keep_newest must not be negative")
+	}
+	maxBytes, err := r.MaxTotalBytes()
+	if err != nil {
+		return err
+	}
+	if r.MaxAgeDays == 0 && maxBytes == 0 && r.KeepNewest == 0 {
+		return fmt.Errorf("rule sets none of max_age_days, max_total_size or keep_newest")
+	}
+	return nil
+}
+
+// FileConfig is the on-disk layout of config.json.
+type FileConfig struct {
+	DefaultProfile string              `json:"default_profile,omitempty"`
+	Profiles       map[string]*Profile `json:"profiles"`
+}
+
+// Setting is a resolved value together with a description of where it came from.
+type Setting struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// Settings is the fully resolved local configuration for one run.
+type Settings struct {
+	ConfigPath   string
+	ConfigLoaded bool // Whether ConfigPath existed and was read
+	Profile      Setting
+
+	BundleURL       Setting
+	ChatIDURL       Setting
+	DefaultFolderID Setting
+	DDLBaseURL      Setting
+
+	SigningPublicKey Setting
+	BundleSHA256     Setting
+	ChatIDSHA256     Setting
+
+	ConfigMaxStaleness Setting
+
+	Proxy          Setting
+	CAFile         Setting
+	ConnectTimeout Setting
+	ReadTimeout    Setting
+
+	File *FileConfig
+}
+
+// DefaultConfigPath returns the per-user config file location,
+// e.g. ~/.config/penguindex/config.json on Linux.
+func DefaultConfigPath() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user config directory: %w", err)
+	}
+	return filepath.Join(base, utils.APP_DIR_NAME, CONFIG_FILE_NAME), nil
+}
+
+// LoadSettings resolves every setting in order of precedence:
+// PENGUINDEX_* environment variables, then the selected profile in the config
+// file, then the compiled-in constants. configPath and profile come from the
+// global -config/-profile flags and may be empty.
+func LoadSettings(configPath, profile string) (*Settings, error) {
+	settings := &Settings{File: &FileConfig{Profiles: map[string]*Profile{}}}
+
+	explicitPath := true
+	switch {
+	case configPath != "":
+	case os.Getenv(ENV_CONFIG) != "":
+		configPath = os.Getenv(ENV_CONFIG)
+	default:
+		explicitPath = false
+		var err error
+		if configPath, err = DefaultConfigPath(); err != nil {
+			return nil, err
+		}
+	}
+	settings.ConfigPath = configPath
+
+	if err := utils.ReadJSONFile(configPath, settings.File); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) || explicitPath {
+			return nil, fmt.Errorf("failed to load config file: %w", err)
+		}
+	} else {
+		settings.ConfigLoaded = true
+		if settings.File.Profiles == nil {
+			settings.File.Profiles = map[string]*Profile{}
+		}
+	}
+
+	switch {
+	case profile != "":
+		settings.Profile = Setting{profile, "-profile flag"}
+	case os.Getenv(ENV_PROFILE) != "":
+		settings.Profile = Setting{os.Getenv(ENV_PROFILE), "env " + ENV_PROFILE}
+	case settings.File.DefaultProfile != "":
+		settings.Profile = Setting{settings.File.DefaultProfile, "config file default_profile"}
+	default:
+		settings.Profile = Setting{DEFAULT_PROFILE_NAME, "built-in default"}
+	}
+
+	selected, ok := settings.File.Profiles[settings.Profile.Value]
+	if !ok {
+		if settings.Profile.Value != DEFAULT_PROFILE_NAME {
+			return nil, fmt.Errorf("profile %q not found in %s", settings.Profile.Value, configPath)
+		}
+		selected = &Profile{}
+		settings.File.Profiles[DEFAULT_PROFILE_NAME] = selected
+	}
+
+	fileSource := fmt.Sprintf("%s (profile %s)", configPath, settings.Profile.Value)
+	settings.BundleURL = resolve(ENV_BUNDLE_URL, selected.BundleURL, fileSource, EMBEDDED_BUNDLE_URL)
+	settings.ChatIDURL = resolve(ENV_CHAT_ID_URL, selected.ChatIDURL, fileSource, TELEGRAM_CHAT_ID_URL)
+	settings.DefaultFolderID = resolve(ENV_DEFAULT_FOLDER_ID, selected.DefaultFolderID, fileSource, DEFAULT_TEST_FOLDER_ID)
+	settings.DDLBaseURL = resolve(ENV_DDL_BASE_URL, selected.DDLBaseURL, fileSource, "")
+	settings.SigningPublicKey = resolve(ENV_SIGNING_KEY, selected.SigningPublicKey, fileSource, EMBEDDED_SIGNING_PUBLIC_KEY)
+	settings.BundleSHA256 = resolve(ENV_BUNDLE_SHA256, selected.BundleSHA256, fileSource, EMBEDDED_BUNDLE_SHA256)
+	settings.ChatIDSHA256 = resolve(ENV_CHAT_ID_SHA256, selected.ChatIDSHA256, fileSource, EMBEDDED_CHAT_ID_SHA256)
+	settings.ConfigMaxStaleness = resolve(ENV_MAX_STALENESS, selected.ConfigMaxStaleness, fileSource, DEFAULT_CONFIG_MAX_STALENESS)
+	settings.Proxy = resolve(ENV_PROXY, selected.Proxy, fileSource, "")
+	settings.CAFile = resolve(ENV_CA_FILE, selected.CAFile, fileSource, "")
+	settings.ConnectTimeout = resolve(ENV_CONNECT_TIMEOUT, selected.ConnectTimeout, fileSource, httpclient.DEFAULT_CONNECT_TIMEOUT.String())
+	settings.ReadTimeout = resolve(ENV_READ_TIMEOUT, selected.ReadTimeout, fileSource, httpclient.DEFAULT_READ_TIMEOUT.String())
+	return settings, nil
+}
+
+// resolve picks the first non-empty value of the environment variable, the
+// profile value and the compiled fallback.
+func resolve(envName, fileValue, fileSource, compiled string) Setting {
+	if value := os.Getenv(envName); value != "" {
+		return Setting{value, "env " + envName}
+	}
+	if fileValue != "" {
+		return Setting{fileValue, fileSource}
+	}
+	return Setting{compiled, "compiled default"}
+}
+
+// ActiveProfile returns the selected profile from the config file.
+func (s *Settings) ActiveProfile() *Profile {
+	return s.File.Profiles[s.Profile.Value]
+}
+
+// FolderAliases returns the alias table of the selected profile.
+func (s *Settings) FolderAliases() map[string]string {
+	return s.ActiveProfile().Folders
+}
+
+// RetentionRules returns the prune rules of the selected profile.
+func (s *Settings) RetentionRules() map[string]*RetentionRule {
+	return s.ActiveProfile().Retention
+}
+
+// MaxStaleness parses ConfigMaxStaleness.
+func (s *Settings) MaxStaleness() (time.Duration, error) {
+	return parseDurationSetting("config_max_staleness", s.ConfigMaxStaleness, true)
+}
+
+// HTTPOptions returns the network settings for httpclient.Configure.
+func (s *Settings) HTTPOptions() (httpclient.Options, error) {
+	connectTimeout, err := parseDurationSetting("connect_timeout", s.ConnectTimeout, false)
+	if err != nil {
+		return httpclient.Options{}, err
+	}
+	readTimeout, err := parseDurationSetting("read_timeout", s.ReadTimeout, false)
+	if err != nil {
+		return httpclient.Options{}, err
+	}
+	return httpclient.Options{
+		ConnectTimeout: connectTimeout,
+		ReadTimeout:    readTimeout,
+		Proxy:          s.Proxy.Value,
+		CAFile:         s.CAFile.Value,
+	}, nil
+}
+
+// parseDurationSetting parses a Go duration such as "72h", allowing 0 only
+// if allowZero is set.
+func parseDurationSetting(name string, setting Setting, allowZero bool) (time.Duration, error) {
+	duration, err := time.ParseDuration(setting.Value)
+	if err != nil || duration < 0 || (duration == 0 && !allowZero) {
+		return 0, fmt.Errorf("invalid %s %q from %s (want a duration such as 30s or 72h)", name, setting.Value, setting.Source)
+	}
+	return duration, nil
+}
+
+// Save writes the config file back to ConfigPath, creating its directory if needed.
+func (s *Settings) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.ConfigPath), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := utils.WriteJSONFile(s.ConfigPath, s.File); err != nil {
+		return err
+	}
+	s.ConfigLoaded = true
+	return nil
+}
@@ -0,0 +1,272 @@
+// File: penguindex-go/internal/config/signing.go
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Signatures are made over a context string, the document's envelope (see
+// SignedEnvelope) and the signed value, so a bundle signature can never pass
+// as a chat ID signature or vice versa.
+const (
+	bundleSignatureContext = "penguindex-bundle-v2\n"
+	chatIDSignatureContext = "penguindex-chat-id-v2\n"
+)
+
+// SignedEnvelope is what a signature covers besides the value.
+// IssuedAt orders the releases of a document: a copy issued before the one
+// already cached for its URL is refused, so an old bundle or chat ID cannot
+// be served again once it has been replaced. URL, if set, is the only
+// address the document may be fetched from.
+type SignedEnvelope struct {
+	IssuedAt int64  `json:"issued_at,omitempty"` // Unix seconds
+	URL      string `json:"url,omitempty"`       // Intended URL; empty allows any
+}
+
+// signedMessage returns the bytes signed for value under this envelope.
+func (e SignedEnvelope) signedMessage(context, value string) []byte {
+	return []byte(fmt.Sprintf("%sissued_at=%d\nurl=%s\n%s", context, e.IssuedAt, e.URL, value))
+}
+
+// check confirms the envelope fits a document fetched from url. A URL
+// cannot contain a newline, so the signed fields cannot be shifted.
+func (e SignedEnvelope) check(url string) error {
+	if e.IssuedAt <= 0 {
+		return fmt.Errorf("signature has no issued_at time; re-sign the document")
+	}
+	if strings.ContainsAny(e.URL, "\r\n") {
+		return fmt.Errorf("signed URL contains a line break")
+	}
+	if e.URL != "" && url != "" && e.URL != url {
+		return fmt.Errorf("document was signed for %s, not %s", e.URL, url)
+	}
+	return nil
+}
+
+// newEnvelope stamps a document signed now for url.
+func newEnvelope(url string) (SignedEnvelope, error) {
+	envelope := SignedEnvelope{IssuedAt: time.Now().Unix(), URL: url}
+	return envelope, envelope.check("")
+}
+
+// SignedChatID is the signed form of the chat ID document. An unsigned
+// document is the bare chat ID in plain text.
+type SignedChatID struct {
+	ChatID string `json:"chat_id"`
+	SignedEnvelope
+	Signature string `json:"signature"` // base64 Ed25519 signature
+}
+
+// Verifier checks the remote bundle and chat ID documents before they are
+// used. With a public key both must carry valid signatures; with pinned
+// digests both must hash to them exactly as served.
+type Verifier struct {
+	PublicKey    ed25519.PublicKey
+	BundleSHA256 []byte
+	ChatIDSHA256 []byte
+}
+
+// NewVerifier builds a Verifier from the resolved settings. A bundle digest
+// without a chat ID digest is refused, since the chat ID would be unchecked.
+func NewVerifier(settings *Settings) (*Verifier, error) {
+	v := &Verifier{}
+	if key := settings.SigningPublicKey.Value; key != "" {
+		raw, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("signing public key from %s is not a base64 Ed25519 public key", settings.SigningPublicKey.Source)
+		}
+		v.PublicKey = raw
+		return v, nil
+	}
+	if settings.BundleSHA256.Value == "" && settings.ChatIDSHA256.Value == "" {
+		return v, nil
+	}
+	if settings.BundleSHA256.Value == "" || settings.ChatIDSHA256.Value == "" {
+		return nil, fmt.Errorf("bundle_sha256 and chat_id_sha256 must be set together, or use signing_public_key")
+	}
+	var err error
+	if v.BundleSHA256, err = parseDigest(settings.BundleSHA256); err != nil {
+		return nil, err
+	}
+	if v.ChatIDSHA256, err = parseDigest(settings.ChatIDSHA256); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func parseDigest(setting Setting) ([]byte, error) {
+	digest, err := hex.DecodeString(strings.TrimSpace(setting.Value))
+	if err != nil || len(digest) != sha256.Size {
+		return nil, fmt.Errorf("SHA-256 digest from %s is not 64 hex characters", setting.Source)
+	}
+	return digest, nil
+}
+
+// Enabled reports whether any trust anchor is configured. A nil Verifier
+// verifies nothing.
+func (v *Verifier) Enabled() bool {
+	return v != nil && (v.PublicKey != nil || v.BundleSHA256 != nil)
+}
+
+// ordersReleases reports whether documents must carry signed issue times
+// that only move forward: with a signing key. Pinned digests admit exactly
+// one document, so they already rule out older ones.
+func (v *Verifier) ordersReleases() bool {
+	return v != nil && v.PublicKey != nil
+}
+
+// verifyBundle checks the bundle document raw, already parsed as doc and
+// fetched from url. issuedAt is the signed issue time, or 0 if no signing
+// key is configured.
+func (v *Verifier) verifyBundle(raw []byte, doc *RemoteEncryptedBundle, url string) (issuedAt int64, err error) {
+	switch {
+	case !v.Enabled():
+		return 0, nil
+	case v.PublicKey != nil:
+		if doc.Signature == "" {
+			return 0, fmt.Errorf("bundle is not signed")
+		}
+		if err := doc.check(url); err != nil {
+			return 0, err
+		}
+		message := doc.signedMessage(bundleSignatureContext, doc.EncryptedBundle)
+		return doc.IssuedAt, verifySignature(v.PublicKey, message, doc.Signature)
+	default:
+		return 0, checkDigest(raw, v.BundleSHA256)
+	}
+}
+
+// verifiedChatID checks the chat ID document raw, fetched from url, and
+// returns the chat ID and, as for verifyBundle, its signed issue time.
+func (v *Verifier) verifiedChatID(raw []byte, url string) (chatID string, issuedAt int64, err error) {
+	text := strings.TrimSpace(string(raw))
+	var signed SignedChatID
+	isSigned := strings.HasPrefix(text, "{")
+	if isSigned {
+		if err := json.Unmarshal(raw, &signed); err != nil {
+			return "", 0, fmt.Errorf("failed to parse signed chat ID document: %w", err)
+		}
+	}
+
+	switch {
+	case !v.Enabled():
+	case v.PublicKey != nil:
+		if !isSigned || signed.Signature == "" {
+			return "", 0, fmt.Errorf("chat ID document is not signed")
+		}
+		if err := signed.check(url); err != nil {
+			return "", 0, err
+		}
+		message := signed.signedMessage(chatIDSignatureContext, signed.ChatID)
+		if err := verifySignature(v.PublicKey, message, signed.Signature); err != nil {
+			return "", 0, err
+		}
+		issuedAt = signed.IssuedAt
+	default:
+		if err := checkDigest(raw, v.ChatIDSHA256); err != nil {
+			return "", 0, err
+		}
+	}
+	if isSigned {
+		return signed.ChatID, issuedAt, nil
+	}
+	return text, 0, nil
+}
+
+func verifySignature(publicKey ed25519.PublicKey, message []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("signature is not valid base64: %w", err)
+	}
+	if !ed25519.Verify(publicKey, message, sig) {
+		return fmt.Errorf("signature does not match the configured signing key")
+	}
+	return nil
+}
+
+func checkDigest(raw, want []byte) error {
+	got := sha256.Sum256(raw)
+	if subtle.ConstantTimeCompare(got[:], want) != 1 {
+		return fmt.Errorf("SHA-256 %x does not match the pinned digest %x", got, want)
+	}
+	return nil
+}
+
+// GenerateSigningKey creates an Ed25519 key pair, writes the private key
+// base64-encoded to path with user-only permissions and returns the public key.
+func GenerateSigningKey(path string) (string, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create key file: %w", err)
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintln(file, base64.StdEncoding.EncodeToString(privateKey)); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return base64.StdEncoding.EncodeToString(publicKey), nil
+}
+
+// LoadSigningKey reads a private key written by GenerateSigningKey. Like a
+// PIN file, it is refused if other users can access it.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	encoded, err := readSecretFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%s is not a base64 Ed25519 private key", path)
+	}
+	return raw, nil
+}
+
+// SignBundle returns the bundle document for a hex-encoded bundle, signed
+// now for url (empty for any URL).
+func SignBundle(privateKey ed25519.PrivateKey, hexEncodedEncryptedBundle, url string) (*RemoteEncryptedBundle, error) {
+	envelope, err := newEnvelope(url)
+	if err != nil {
+		return nil, err
+	}
+	message := envelope.signedMessage(bundleSignatureContext, hexEncodedEncryptedBundle)
+	return &RemoteEncryptedBundle{
+		EncryptedBundle: hexEncodedEncryptedBundle,
+		SignedEnvelope:  envelope,
+		Signature:       base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, message)),
+	}, nil
+}
+
+// SignChatID returns the chat ID document, signed now for url (empty for any URL).
+func SignChatID(privateKey ed25519.PrivateKey, chatID, url string) (*SignedChatID, error) {
+	envelope, err := newEnvelope(url)
+	if err != nil {
+		return nil, err
+	}
+	message := envelope.signedMessage(chatIDSignatureContext, chatID)
+	return &SignedChatID{
+		ChatID:         chatID,
+		SignedEnvelope: envelope,
+		Signature:      base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, message)),
+	}, nil
+}
+
+// PublicKeyOf returns the base64 public key of privateKey, for signing_public_key.
+func PublicKeyOf(privateKey ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(privateKey.Public().(ed25519.PublicKey))
+}
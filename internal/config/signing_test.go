@@ -0,0 +1,227 @@
+// File: penguindex-go/internal/config/signing_test.go
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const (
+	testBundleURL = "https://example.com/bundle.json"
+	testChatURL   = "https://example.com/chat_id.txt"
+	testChatID    = "-1001234567890"
+)
+
+func newTestKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return publicKey, privateKey
+}
+
+// mustJSON encodes v, as a document would be served.
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// flipByte returns s with the byte at i changed to another valid character.
+func flipByte(s string, i int) string {
+	b := []byte(s)
+	if b[i] == '0' {
+		b[i] = '1'
+	} else {
+		b[i] = '0'
+	}
+	return string(b)
+}
+
+func TestSignedBundleRoundTrip(t *testing.T) {
+	publicKey, privateKey := newTestKey(t)
+	verifier := &Verifier{PublicKey: publicKey}
+	doc, err := SignBundle(privateKey, "00aabbccdd", testBundleURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuedAt, err := verifier.verifyBundle(mustJSON(t, doc), doc, testBundleURL)
+	if err != nil {
+		t.Fatalf("verifyBundle: %v", err)
+	}
+	if issuedAt == 0 || issuedAt != doc.IssuedAt {
+		t.Errorf("issuedAt = %d, want the signed %d", issuedAt, doc.IssuedAt)
+	}
+}
+
+func TestSignedChatIDRoundTrip(t *testing.T) {
+	publicKey, privateKey := newTestKey(t)
+	verifier := &Verifier{PublicKey: publicKey}
+	doc, err := SignChatID(privateKey, testChatID, testChatURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chatID, issuedAt, err := verifier.verifiedChatID(mustJSON(t, doc), testChatURL)
+	if err != nil {
+		t.Fatalf("verifiedChatID: %v", err)
+	}
+	if chatID != testChatID || issuedAt != doc.IssuedAt {
+		t.Errorf("verifiedChatID = %q, %d; want %q, %d", chatID, issuedAt, testChatID, doc.IssuedAt)
+	}
+}
+
+func TestVerifyBundleRejectsTampering(t *testing.T) {
+	publicKey, privateKey := newTestKey(t)
+	verifier := &Verifier{PublicKey: publicKey}
+	const otherURL = "https://example.com/other.json"
+
+	tests := []struct {
+		name   string
+		tamper func(doc *RemoteEncryptedBundle)
+		url    string // Where the document is fetched from
+	}{
+		{name: "value", tamper: func(doc *RemoteEncryptedBundle) { doc.EncryptedBundle = flipByte(doc.EncryptedBundle, 3) }},
+		{name: "issued_at", tamper: func(doc *RemoteEncryptedBundle) { doc.IssuedAt++ }},
+		{name: "url", tamper: func(doc *RemoteEncryptedBundle) { doc.URL = otherURL }, url: otherURL},
+		{name: "signature", tamper: func(doc *RemoteEncryptedBundle) { doc.Signature = flipByte(doc.Signature, 5) }},
+		{name: "signed for another URL", tamper: func(doc *RemoteEncryptedBundle) {}, url: otherURL},
+		{name: "missing issued_at", tamper: func(doc *RemoteEncryptedBundle) { doc.IssuedAt = 0 }},
+		{name: "unsigned", tamper: func(doc *RemoteEncryptedBundle) { doc.SignedEnvelope, doc.Signature = SignedEnvelope{}, "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := SignBundle(privateKey, "00aabbccdd", testBundleURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.tamper(doc)
+			url := tt.url
+			if url == "" {
+				url = testBundleURL
+			}
+			if _, err := verifier.verifyBundle(mustJSON(t, doc), doc, url); err == nil {
+				t.Error("verifyBundle accepted the document")
+			}
+		})
+	}
+}
+
+func TestVerifiedChatIDRejectsTampering(t *testing.T) {
+	publicKey, privateKey := newTestKey(t)
+	verifier := &Verifier{PublicKey: publicKey}
+
+	tests := []struct {
+		name string
+		raw  func(doc *SignedChatID) []byte
+	}{
+		{name: "value", raw: func(doc *SignedChatID) []byte {
+			doc.ChatID = flipByte(doc.ChatID, 5)
+			return mustJSON(t, doc)
+		}},
+		{name: "issued_at", raw: func(doc *SignedChatID) []byte {
+			doc.IssuedAt--
+			return mustJSON(t, doc)
+		}},
+		{name: "url", raw: func(doc *SignedChatID) []byte {
+			doc.URL = "https://example.com/other.txt"
+			return mustJSON(t, doc)
+		}},
+		{name: "unsigned plain text", raw: func(doc *SignedChatID) []byte { return []byte(testChatID + "\n") }},
+		{name: "unsigned JSON", raw: func(doc *SignedChatID) []byte {
+			doc.Signature = ""
+			return mustJSON(t, doc)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := SignChatID(privateKey, testChatID, testChatURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if chatID, _, err := verifier.verifiedChatID(tt.raw(doc), testChatURL); err == nil {
+				t.Errorf("verifiedChatID accepted the document, returning %q", chatID)
+			}
+		})
+	}
+}
+
+func TestSignatureContextsAreSeparate(t *testing.T) {
+	publicKey, privateKey := newTestKey(t)
+	verifier := &Verifier{PublicKey: publicKey}
+
+	// Sign a bundle whose value is a plausible chat ID, then serve it as one.
+	bundle, err := SignBundle(privateKey, testChatID, testChatURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	asChatID := SignedChatID{ChatID: bundle.EncryptedBundle, SignedEnvelope: bundle.SignedEnvelope, Signature: bundle.Signature}
+	if _, _, err := verifier.verifiedChatID(mustJSON(t, asChatID), testChatURL); err == nil {
+		t.Error("a bundle signature was accepted for a chat ID")
+	}
+
+	chat, err := SignChatID(privateKey, testChatID, testBundleURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	asBundle := &RemoteEncryptedBundle{EncryptedBundle: chat.ChatID, SignedEnvelope: chat.SignedEnvelope, Signature: chat.Signature}
+	if _, err := verifier.verifyBundle(mustJSON(t, asBundle), asBundle, testBundleURL); err == nil {
+		t.Error("a chat ID signature was accepted for a bundle")
+	}
+}
+
+func TestPinnedDigests(t *testing.T) {
+	bundleRaw := []byte(`{"encrypted_bundle":"00aabbccdd"}`)
+	chatRaw := []byte(testChatID + "\n")
+	bundleSum, chatSum := sha256.Sum256(bundleRaw), sha256.Sum256(chatRaw)
+	verifier := &Verifier{BundleSHA256: bundleSum[:], ChatIDSHA256: chatSum[:]}
+
+	var doc RemoteEncryptedBundle
+	if err := json.Unmarshal(bundleRaw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.verifyBundle(bundleRaw, &doc, testBundleURL); err != nil {
+		t.Errorf("verifyBundle with the pinned digest: %v", err)
+	}
+	if chatID, _, err := verifier.verifiedChatID(chatRaw, testChatURL); err != nil || chatID != testChatID {
+		t.Errorf("verifiedChatID with the pinned digest = %q, %v; want %q", chatID, err, testChatID)
+	}
+
+	changed := []byte(strings.Replace(string(bundleRaw), "00", "01", 1))
+	if _, err := verifier.verifyBundle(changed, &doc, testBundleURL); err == nil {
+		t.Error("verifyBundle accepted a bundle that does not match the pinned digest")
+	}
+	if _, _, err := verifier.verifiedChatID([]byte("-1009999999999\n"), testChatURL); err == nil {
+		t.Error("verifiedChatID accepted a chat ID that does not match the pinned digest")
+	}
+}
+
+func TestNewVerifierRequiresBothDigests(t *testing.T) {
+	digest := Setting{Value: hex.EncodeToString(make([]byte, sha256.Size)), Source: "test"}
+	tests := []struct {
+		name     string
+		settings Settings
+		wantErr  bool
+	}{
+		{name: "nothing configured", settings: Settings{}},
+		{name: "both digests", settings: Settings{BundleSHA256: digest, ChatIDSHA256: digest}},
+		{name: "bundle digest alone", settings: Settings{BundleSHA256: digest}, wantErr: true},
+		{name: "chat ID digest alone", settings: Settings{ChatIDSHA256: digest}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewVerifier(&tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewVerifier error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// File: penguindex-go/internal/gdrive/download.go
+package gdrive
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"github.com/fatih/color"
+)
+
+// PART_FILE_SUFFIX marks an incomplete download next to its final path.
+const PART_FILE_SUFFIX = ".part"
+
+// DownloadFields is the metadata DownloadFile needs about a file, plus the
+// Shared Drive it is in for the bulk delete preview.
+const DownloadFields = "id, name, mimeType, size, md5Checksum, modifiedTime, driveId"
+
+// googleAppsMimePrefix identifies Docs/Sheets/Slides files, which have no
+// binary content and can only be exported.
+const googleAppsMimePrefix = "application/vnd.google-apps."
+
+// IsGoogleAppsFile reports whether file is a Docs/Sheets/Slides (or other
+// Google-native) file that DownloadFile cannot fetch. Folders are not.
+func IsGoogleAppsFile(file *drive.File) bool {
+	return strings.HasPrefix(file.MimeType, googleAppsMimePrefix) && !IsFolder(file)
+}
+
+// GetFileMetadata fetches the fields DownloadFile needs, including for Shared Drive items.
+func GetFileMetadata(svc *drive.Service, fileID string) (*drive.File, error) {
+	file, err := retryCall("metadata lookup for "+fileID, func() (*drive.File, error) {
+		return svc.Files.Get(fileID).SupportsAllDrives(true).Fields(DownloadFields).Do()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata for '%s': %w", fileID, err)
+	}
+	return file, nil
+}
+
+// DownloadFile downloads file (with metadata from GetFileMetadata) to
+// destPath. Data is written to destPath+".part" first; an existing part file
+// is resumed with an HTTP Range request rather than downloaded again. The
+// result is checked against Drive's md5Checksum before it is renamed into place.
+func DownloadFile(svc *drive.Service, file *drive.File, destPath string) error {
+	if IsGoogleAppsFile(file) {
+		return fmt.Errorf("'%s' is a Google Docs editor file (%s) and has no downloadable content", file.Name, file.MimeType)
+	}
+
+	partPath := destPath + PART_FILE_SUFFIX
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to inspect %s: %w", partPath, err)
+	}
+	if offset > file.Size {
+		offset = 0 // The remote file shrank since the part was written.
+	}
+
+	partFile, err := os.OpenFile(partPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", partPath, err)
+	}
+	defer partFile.Close()
+
+	// Hash what is already on disk so the final check covers the whole file.
+	hasher := md5.New()
+	if offset > 0 {
+		if _, err := io.Copy(hasher, io.NewSectionReader(partFile, 0, offset)); err != nil {
+			return fmt.Errorf("failed to hash %s: %w", partPath, err)
+		}
+	}
+
+	if offset < file.Size {
+		// A retry picks up at the offset the failed attempt reached.
+		err = DefaultRetryPolicy.Do("download of "+file.Name, nil, func() error {
+			var err error
+			offset, err = fetchRange(svc, file, partFile, hasher, offset)
+			return err
+		})
+		if err != nil {
+			return err // The part file is kept so the next run can resume.
+		}
+	}
+	if err := partFile.Truncate(offset); err != nil {
+		return fmt.Errorf("failed to truncate %s: %w", partPath, err)
+	}
+	if err := partFile.Sync(); err != nil {
+		return fmt.Errorf("failed to flush %s: %w", partPath, err)
+	}
+
+	localMD5 := hex.EncodeToString(hasher.Sum(nil))
+	if file.Md5Checksum == "" {
+		utils.Warn(color.YellowString("Warning: Drive returned no md5Checksum for %s; integrity not verified.", file.Name))
+	} else if !strings.EqualFold(localMD5, file.Md5Checksum) {
+		// A corrupt part file must not be resumed from.
+		partFile.Close()
+		_ = os.Remove(partPath)
+		return &ChecksumMismatchError{
+			FileName:  file.Name,
+			FileID:    file.Id,
+			LocalMD5:  localMD5,
+			RemoteMD5: file.Md5Checksum,
+		}
+	}
+
+	if err := partFile.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", partPath, err)
+	}
+	if err := os.Rename(partPath, destPath); err != nil {
+		return fmt.Errorf("failed to move %s into place: %w", partPath, err)
+	}
+	if modified, err := time.Parse(time.RFC3339, file.ModifiedTime); err == nil {
+		_ = os.Chtimes(destPath, modified, modified)
+	}
+	return nil
+}
+
+// fetchRange streams file from offset to its end into partFile and hasher and
+// returns the new end offset. If Drive ignores the Range header and sends the
+// whole file, the part file and hash are restarted from zero.
+func fetchRange(svc *drive.Service, file *drive.File, partFile *os.File, hasher hash.Hash, offset int64) (int64, error) {
+	call := svc.Files.Get(file.Id).SupportsAllDrives(true)
+	if offset > 0 {
+		call.Header().Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := call.Download()
+	if err != nil {
+		return offset, fmt.Errorf("failed to download '%s': %w", file.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if offset > 0 && resp.StatusCode != http.StatusPartialContent {
+		offset = 0
+		hasher.Reset()
+	}
+	if offset > 0 {
+		utils.Status(color.CyanString("Resuming download of %s at %s of %s",
+			file.Name, utils.HumanReadableSize(uint64(offset)), utils.HumanReadableSize(uint64(file.Size))))
+	}
+	if _, err := partFile.Seek(offset, io.SeekStart); err != nil {
+		return offset, fmt.Errorf("failed to seek %s: %w", partFile.Name(), err)
+	}
+
+	bar := newTransferBar(max(file.Size, 1), fmt.Sprintf("Downloading %s...", file.Name))
+	_ = bar.Set64(offset)
+	written, err := io.Copy(io.MultiWriter(partFile, hasher, bar), resp.Body)
+	offset += written
+	if err != nil {
+		_ = bar.Clear()
+		return offset, fmt.Errorf("failed to download '%s' at offset %d: %w", file.Name, offset, err)
+	}
+	_ = bar.Finish()
+	finishBarLine()
+	if offset != file.Size {
+		return offset, fmt.Errorf("download of '%s' ended at %d of %d bytes", file.Name, offset, file.Size)
+	}
+	return offset, nil
+}
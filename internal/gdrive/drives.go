@@ -0,0 +1,29 @@
+// File: penguindex-go/internal/gdrive/drives.go
+package gdrive
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// ListSharedDrives returns every Shared Drive the authenticated account is a
+// member of. A Shared Drive's ID doubles as the folder ID of its root.
+func ListSharedDrives(svc *drive.Service) ([]*drive.Drive, error) {
+	var drives []*drive.Drive
+	err := DefaultRetryPolicy.Do("Shared Drive listing", nil, func() error {
+		drives = nil
+		return svc.Drives.List().
+			PageSize(100).
+			Fields("nextPageToken", "drives(id, name, createdTime, hidden, capabilities/canAddChildren, capabilities/canDeleteChildren)").
+			Pages(context.Background(), func(page *drive.DriveList) error {
+				drives = append(drives, page.Drives...)
+				return nil
+			})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Shared Drives: %w", err)
+	}
+	return drives, nil
+}
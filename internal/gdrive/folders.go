@@ -0,0 +1,171 @@
+// File: penguindex-go/internal/gdrive/folders.go
+package gdrive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// FolderMimeType is the MIME type Google Drive uses for folders.
+const FolderMimeType = "application/vnd.google-apps.folder"
+
+// CreateFolder creates a new folder named name inside parentID.
+// Drive allows duplicate names, so the first attempt always creates a new
+// folder. A failed attempt may still have created it (a timeout or dropped
+// connection after Drive committed the request), so before each retry the
+// parent is searched for a folder of that name, which is returned instead.
+func CreateFolder(svc *drive.Service, name, parentID string) (*drive.File, error) {
+	folder := &drive.File{
+		Name:     name,
+		MimeType: FolderMimeType,
+	}
+	if parentID != "" {
+		folder.Parents = []string{parentID}
+	}
+
+	var createdFolder *drive.File
+	attempt := 0
+	err := DefaultRetryPolicy.Do("creation of folder "+name, nil, func() error {
+		attempt++
+		if attempt > 1 {
+			existing, err := FindFolder(svc, name, parentID)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				createdFolder = existing
+				return nil
+			}
+		}
+		var err error
+		createdFolder, err = svc.Files.Create(folder).SupportsAllDrives(true).Fields("id", "name", "parents", "webViewLink").Do()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create folder '%s' in '%s': %w", name, parentID, err)
+	}
+	return createdFolder, nil
+}
+
+// FindFolder returns the oldest non-trashed folder named name directly inside
+// parentID, or nil if there is none.
+func FindFolder(svc *drive.Service, name, parentID string) (*drive.File, error) {
+	query := fmt.Sprintf("name = '%s' and '%s' in parents and mimeType = '%s' and trashed = false",
+		escapeQueryString(name), escapeQueryString(parentID), FolderMimeType)
+	list, err := retryCall("lookup of folder "+name, func() (*drive.FileList, error) {
+		return allDrives(svc.Files.List()).
+			Q(query).
+			OrderBy("createdTime").
+			PageSize(1).
+			Fields("files(id, name, parents, webViewLink)").
+			Do()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up folder '%s' in '%s': %w", name, parentID, err)
+	}
+	if len(list.Files) == 0 {
+		return nil, nil
+	}
+	return list.Files[0], nil
+}
+
+// FindOrCreateFolder returns the folder named name inside parentID, creating
+// it only if it does not exist yet. created reports whether it was created.
+func FindOrCreateFolder(svc *drive.Service, name, parentID string) (folder *drive.File, created bool, err error) {
+	folder, err = FindFolder(svc, name, parentID)
+	if err != nil || folder != nil {
+		return folder, false, err
+	}
+	folder, err = CreateFolder(svc, name, parentID)
+	return folder, err == nil, err
+}
+
+// FolderLink returns the browser link for a Drive folder ID.
+func FolderLink(folderID string) string {
+	return fmt.Sprintf("https://drive.google.com/drive/folders/%s", folderID)
+}
+
+// escapeQueryString escapes a value for use inside single quotes in a Drive query.
+func escapeQueryString(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+}
+
+// FindFilesByName returns the non-trashed, non-folder files named name directly inside folderID.
+func FindFilesByName(svc *drive.Service, folderID, name string) ([]*drive.File, error) {
+	query := fmt.Sprintf("name = '%s' and '%s' in parents and trashed = false and mimeType != '%s'",
+		escapeQueryString(name), escapeQueryString(folderID), FolderMimeType)
+	files, err := findFiles(svc, query, "search for "+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search folder '%s' for '%s': %w", folderID, name, err)
+	}
+	return files, nil
+}
+
+// FindFilesByNamePrefix returns the non-trashed, non-folder files directly
+// inside folderID whose name starts with prefix. Drive's "contains" operator
+// also matches later words of a name, so the result is filtered here.
+func FindFilesByNamePrefix(svc *drive.Service, folderID, prefix string) ([]*drive.File, error) {
+	query := fmt.Sprintf("name contains '%s' and '%s' in parents and trashed = false and mimeType != '%s'",
+		escapeQueryString(prefix), escapeQueryString(folderID), FolderMimeType)
+	found, err := findFiles(svc, query, "search for "+prefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to search folder '%s' for '%s*': %w", folderID, prefix, err)
+	}
+	var files []*drive.File
+	for _, file := range found {
+		if strings.HasPrefix(file.Name, prefix) {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// findFiles returns every file matching query, retrying the whole listing on transient errors.
+func findFiles(svc *drive.Service, query, desc string) ([]*drive.File, error) {
+	var files []*drive.File
+	err := DefaultRetryPolicy.Do(desc, nil, func() error {
+		files = nil
+		return allDrives(svc.Files.List()).
+			Q(query).
+			Fields("nextPageToken", "files(id, name, mimeType, size, md5Checksum, createdTime, webViewLink, parents)").
+			Pages(context.Background(), func(page *drive.FileList) error {
+				files = append(files, page.Files...)
+				return nil
+			})
+	})
+	return files, err
+}
+
+// FolderName returns the name of a folder (or any file) by ID.
+func FolderName(svc *drive.Service, folderID string) (string, error) {
+	folder, err := retryCall("lookup of folder "+folderID, func() (*drive.File, error) {
+		return svc.Files.Get(folderID).SupportsAllDrives(true).Fields("name").Do()
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up folder '%s': %w", folderID, err)
+	}
+	return folder.Name, nil
+}
+
+// CheckFolderWritable confirms that folderID exists, is a folder, is not
+// trashed and accepts new children from the authenticated account.
+func CheckFolderWritable(svc *drive.Service, folderID string) (*drive.File, error) {
+	folder, err := retryCall("lookup of folder "+folderID, func() (*drive.File, error) {
+		return svc.Files.Get(folderID).SupportsAllDrives(true).Fields("id", "name", "mimeType", "trashed", "capabilities/canAddChildren").Do()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up folder '%s': %w", folderID, err)
+	}
+	switch {
+	case folder.MimeType != FolderMimeType:
+		return folder, fmt.Errorf("'%s' (%s) is not a folder", folder.Name, folderID)
+	case folder.Trashed:
+		return folder, fmt.Errorf("folder '%s' (%s) is in the trash", folder.Name, folderID)
+	case folder.Capabilities == nil || !folder.Capabilities.CanAddChildren:
+		return folder, fmt.Errorf("folder '%s' (%s) is not writable by this account", folder.Name, folderID)
+	}
+	return folder, nil
+}
@@ -2,14 +2,19 @@
 package gdrive
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
-	"time"
+	"strings"
 
+	"github.com/jendermine/penguindex-go/internal/utils"
 	"github.com/schollz/progressbar/v3" // Progress bar
 	"google.golang.org/api/drive/v3"
 	"github.com/fatih/color"
@@ -19,13 +24,32 @@ import (
 type ProgressTrackingFileReader struct {
 	File     *os.File
 	Size     int64
-	Bar      *progressbar.ProgressBar
-	Reader   io.Reader // This will be io.TeeReader if progress bar is used
+	Bar      *progressbar.ProgressBar // Per-file bar; nil when reporting to Shared
+	Shared   *MultiProgress           // Aggregate bar for concurrent uploads, or nil
+	Reader   io.Reader                // io.TeeReader feeding progressCounter
 	FileName string
+
+	pos        int64
+	milestones *fileMilestones
+	hasher     hash.Hash
+	hashedUpTo int64 // Bytes [0, hashedUpTo) have been fed to hasher
+}
+
+// progressCounter receives the bytes read through the TeeReader.
+type progressCounter struct {
+	p *ProgressTrackingFileReader
+}
+
+func (c progressCounter) Write(b []byte) (int, error) {
+	c.p.hashRange(b)
+	c.p.advance(int64(len(b)))
+	return len(b), nil
 }
 
 // NewProgressTrackingFileReader creates a new reader with a progress bar.
-func NewProgressTrackingFileReader(filePath string) (*ProgressTrackingFileReader, error) {
+// If shared is non-nil, progress is reported to the shared aggregate bar and
+// per-file status lines are printed instead of drawing a dedicated bar.
+func NewProgressTrackingFileReader(filePath string, shared *MultiProgress) (*ProgressTrackingFileReader, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file %s: %w", filePath, err)
@@ -37,30 +61,68 @@ func NewProgressTrackingFileReader(filePath string) (*ProgressTrackingFileReader
 	}
 
 	fileName := filepath.Base(filePath)
-	bar := progressbar.NewOptions64(
-		fileInfo.Size(),
-		progressbar.OptionSetWriter(os.Stdout), // Use os.Stdout or os.Stderr
-		progressbar.OptionEnableColorCodes(true),
-		progressbar.OptionShowBytes(true),
-		progressbar.OptionSetWidth(30),
-		progressbar.OptionSetDescription(color.CyanString(fmt.Sprintf("Uploading %s...", fileName))),
-		progressbar.OptionSetTheme(progressbar.Theme{
-			Saucer:        color.GreenString("="),
-			SaucerHead:    color.GreenString(">"),
-			SaucerPadding: " ",
-			BarStart:      "[",
-			BarEnd:        "]",
-		}),
-		progressbar.OptionThrottle(100*time.Millisecond), // Update progress bar less frequently
-	)
-
-	return &ProgressTrackingFileReader{
+	p := &ProgressTrackingFileReader{
 		File:     file,
 		Size:     fileInfo.Size(),
-		Bar:      bar,
-		Reader:   io.TeeReader(file, bar), // Reads from file, writes to bar
+		Shared:   shared,
 		FileName: fileName,
-	}, nil
+		hasher:   md5.New(),
+	}
+	p.Reader = io.TeeReader(file, progressCounter{p}) // Reads from file, reports to bar
+
+	if shared != nil {
+		p.milestones = &fileMilestones{shared: shared, name: fileName, size: p.Size, next: 25}
+		shared.Println("  %s: started (%s)", fileName, utils.HumanReadableSize(uint64(p.Size)))
+		return p, nil
+	}
+
+	p.Bar = newTransferBar(fileInfo.Size(), fmt.Sprintf("Uploading %s...", fileName))
+	return p, nil
+}
+
+// advance records n more bytes read (negative when rewinding).
+func (p *ProgressTrackingFileReader) advance(n int64) {
+	p.pos += n
+	if p.Bar != nil {
+		_ = p.Bar.Set64(p.pos)
+	}
+	if p.Shared != nil {
+		p.Shared.Add(n)
+		p.milestones.update(p.pos)
+	}
+}
+
+// hashRange feeds the part of b (read at the current position) that has not
+// been hashed yet. Re-sent ranges after a rewind are therefore hashed once.
+func (p *ProgressTrackingFileReader) hashRange(b []byte) {
+	end := p.pos + int64(len(b))
+	if p.pos > p.hashedUpTo || end <= p.hashedUpTo {
+		return
+	}
+	p.hasher.Write(b[p.hashedUpTo-p.pos:])
+	p.hashedUpTo = end
+}
+
+// hashUpTo hashes bytes that were never streamed, e.g. the prefix skipped when
+// resuming an upload, by reading them directly from the file.
+func (p *ProgressTrackingFileReader) hashUpTo(offset int64) error {
+	if offset <= p.hashedUpTo {
+		return nil
+	}
+	section := io.NewSectionReader(p.File, p.hashedUpTo, offset-p.hashedUpTo)
+	if _, err := io.Copy(p.hasher, section); err != nil {
+		return fmt.Errorf("failed to hash %s: %w", p.FileName, err)
+	}
+	p.hashedUpTo = offset
+	return nil
+}
+
+// MD5 returns the hex MD5 of the whole file, hashing any bytes not yet streamed.
+func (p *ProgressTrackingFileReader) MD5() (string, error) {
+	if err := p.hashUpTo(p.Size); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(p.hasher.Sum(nil)), nil
 }
 
 // Read implements io.Reader.
@@ -77,47 +139,298 @@ func (p *ProgressTrackingFileReader) Close() error {
 	return p.File.Close()
 }
 
-// UploadFile uploads a file to Google Drive with progress.
-func UploadFile(svc *drive.Service, filePath string, targetFolderID string) (*drive.File, error) {
-	progressReader, err := NewProgressTrackingFileReader(filePath)
-	if err != nil {
-		return nil, err // Error already contains file path
+// SeekTo repositions the reader at offset and moves the progress bar to match,
+// so resumed or re-sent ranges are not counted twice.
+func (p *ProgressTrackingFileReader) SeekTo(offset int64) error {
+	if err := p.hashUpTo(offset); err != nil {
+		return err
 	}
-	defer progressReader.Close()
+	if _, err := p.File.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek %s to offset %d: %w", p.FileName, offset, err)
+	}
+	p.advance(offset - p.pos)
+	return nil
+}
 
-	mimeType := mime.TypeByExtension(filepath.Ext(progressReader.FileName))
-	if mimeType == "" {
-		mimeType = "application/octet-stream" // Default MIME type
+// status prints a progress line without tearing whichever progress bar is active.
+func (p *ProgressTrackingFileReader) status(line string) {
+	if p.Shared != nil {
+		p.Shared.Println("%s", line)
+		return
 	}
+	utils.Status(line)
+}
 
-	driveFile := &drive.File{
-		Name:     progressReader.FileName,
-		MimeType: mimeType,
+// warn prints a warning on stderr without tearing the aggregate bar.
+func (p *ProgressTrackingFileReader) warn(line string) {
+	if p.Shared != nil {
+		p.Shared.Warn(line)
+		return
 	}
-	if targetFolderID != "" {
-		driveFile.Parents = []string{targetFolderID}
+	utils.Warn(line)
+}
+
+// UploadOptions tunes a single UploadFile call. The zero value uploads with a
+// dedicated per-file progress bar.
+type UploadOptions struct {
+	// Progress, if set, is the shared aggregate bar for concurrent uploads.
+	Progress *MultiProgress
+	// Name overrides the Drive file name; defaults to the local base name.
+	Name string
+	// ExistingFileID, if set, replaces that file's content in place instead of
+	// creating a new file, so its ID and shared links stay the same.
+	ExistingFileID string
+}
+
+// UploadFile uploads a file to Google Drive with progress using a resumable
+// upload session. The session URI and confirmed offset are persisted after
+// every chunk, so re-running the same upload after an interruption continues
+// from the last committed byte instead of starting over.
+// UploadFile is safe for concurrent use with a shared httpClient.
+func UploadFile(httpClient *http.Client, filePath string, targetFolderID string, opts UploadOptions) (*drive.File, error) {
+	progressReader, err := NewProgressTrackingFileReader(filePath, opts.Progress)
+	if err != nil {
+		return nil, err // Error already contains file path
 	}
+	defer progressReader.Close()
 
-	// The Go client library handles resumable uploads automatically for larger files
-	// when Media() is provided with an io.Reader.
-	createdFile, err := svc.Files.Create(driveFile).Media(progressReader).Fields("id", "name", "mimeType", "size", "createdTime", "webViewLink", "webContentLink", "parents").Do()
+	createdFile, err := uploadResumable(httpClient, progressReader, filePath, targetFolderID, opts)
 	if err != nil {
 		// Ensure progress bar is cleared or marked as failed on error
 		if progressReader.Bar != nil {
 			progressReader.Bar.Clear()
 		}
+		if progressReader.Shared != nil {
+			// Count the unsent remainder as processed so the aggregate bar can complete.
+			progressReader.advance(progressReader.Size - progressReader.pos)
+			progressReader.Shared.Println("  %s: %s", progressReader.FileName, color.RedString("failed"))
+		}
 		return nil, fmt.Errorf("failed to upload file '%s' to Google Drive: %w", progressReader.FileName, err)
 	}
 	// Ensure progress bar is explicitly finished on success (if not already by TeeReader)
 	if progressReader.Bar != nil && progressReader.Bar.IsFinished() == false {
 		_ = progressReader.Bar.Finish()
 	}
+
+	if err := verifyUploadChecksum(progressReader, createdFile); err != nil {
+		if progressReader.Shared != nil {
+			progressReader.Shared.Println("  %s: %s", progressReader.FileName, color.RedString("checksum mismatch"))
+		}
+		return nil, err
+	}
+	if progressReader.Shared != nil {
+		progressReader.Shared.Println("  %s: %s", progressReader.FileName, color.GreenString("done, md5 verified"))
+	}
 	return createdFile, nil
 }
 
-// DeleteDriveFile deletes a file from Google Drive by its ID.
+// ChecksumMismatchError reports an upload whose remote MD5 differs from the
+// local file. The remote copy is left in place; FileID identifies it.
+type ChecksumMismatchError struct {
+	FileName  string
+	FileID    string
+	LocalMD5  string
+	RemoteMD5 string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for '%s' (file ID %s): local md5 %s, Drive md5 %s",
+		e.FileName, e.FileID, e.LocalMD5, e.RemoteMD5)
+}
+
+// verifyUploadChecksum compares the streamed MD5 with Drive's md5Checksum.
+func verifyUploadChecksum(progressReader *ProgressTrackingFileReader, createdFile *drive.File) error {
+	localMD5, err := progressReader.MD5()
+	if err != nil {
+		return err
+	}
+	if createdFile.Md5Checksum == "" {
+		progressReader.warn(color.YellowString("Warning: Drive returned no md5Checksum for %s; integrity not verified.", progressReader.FileName))
+		return nil
+	}
+	if !strings.EqualFold(localMD5, createdFile.Md5Checksum) {
+		return &ChecksumMismatchError{
+			FileName:  progressReader.FileName,
+			FileID:    createdFile.Id,
+			LocalMD5:  localMD5,
+			RemoteMD5: createdFile.Md5Checksum,
+		}
+	}
+	return nil
+}
+
+func uploadResumable(httpClient *http.Client, progressReader *ProgressTrackingFileReader, filePath, targetFolderID string, opts UploadOptions) (*drive.File, error) {
+	fileInfo, err := progressReader.File.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file info for %s: %w", filePath, err)
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path of %s: %w", filePath, err)
+	}
+	stateKey := resumeStateKey(absPath, targetFolderID, opts.ExistingFileID)
+	size := progressReader.Size
+
+	var sessionURI string
+	var offset int64
+	if state := lookupResumeState(stateKey, fileInfo); state != nil {
+		var committed int64
+		var createdFile *drive.File
+		err := DefaultRetryPolicy.Do("status query for "+progressReader.FileName, progressReader.warn, func() error {
+			var err error
+			committed, createdFile, err = queryUploadStatus(httpClient, state.SessionURI, size)
+			return err
+		})
+		switch {
+		case err == nil && createdFile != nil:
+			// The previous run finished the transfer but died before recording it.
+			_ = updateResumeState(stateKey, nil)
+			return createdFile, nil
+		case err == nil:
+			sessionURI, offset = state.SessionURI, committed
+			progressReader.status(color.CyanString("Resuming upload of %s at %s of %s",
+				progressReader.FileName, utils.HumanReadableSize(uint64(offset)), utils.HumanReadableSize(uint64(size))))
+		case isSessionGone(err):
+			progressReader.warn(color.YellowString("Saved upload session for %s has expired, starting over.", progressReader.FileName))
+		default:
+			return nil, err
+		}
+	}
+
+	rotator := accountRotator(httpClient)
+	policy := uploadRetryPolicy(rotator)
+	var account string // Service account the session belongs to, when pooled
+	if rotator != nil {
+		account = rotator.CurrentAccount()
+	}
+
+	mimeType := mime.TypeByExtension(filepath.Ext(progressReader.FileName))
+	if mimeType == "" {
+		mimeType = "application/octet-stream" // Default MIME type
+	}
+	driveFile := &drive.File{
+		Name:     progressReader.FileName,
+		MimeType: mimeType,
+	}
+	if opts.Name != "" {
+		driveFile.Name = opts.Name
+	}
+	// Parents cannot be set when updating an existing file.
+	if targetFolderID != "" && opts.ExistingFileID == "" {
+		driveFile.Parents = []string{targetFolderID}
+	}
+	// startSession opens a new session, moving on to the next pooled
+	// account for as long as the current one is out of quota.
+	startSession := func() error {
+		for {
+			err := policy.Do("upload session start for "+progressReader.FileName, progressReader.warn, func() error {
+				var err error
+				sessionURI, err = startResumableSession(httpClient, driveFile, size, opts.ExistingFileID)
+				return err
+			})
+			if err == nil {
+				return nil
+			}
+			next, ok := rotateAfterQuotaError(rotator, progressReader, account, err)
+			if !ok {
+				return err
+			}
+			account = next
+		}
+	}
+	if sessionURI == "" {
+		if err := startSession(); err != nil {
+			return nil, err
+		}
+	}
+
+	state := &ResumeState{
+		SessionURI: sessionURI,
+		FilePath:   absPath,
+		FolderID:   targetFolderID,
+		Size:       size,
+		ModTime:    fileInfo.ModTime(),
+		Offset:     offset,
+	}
+	if err := updateResumeState(stateKey, state); err != nil {
+		progressReader.warn(color.YellowString("Warning: could not save upload state, this upload will not be resumable: %v", err))
+	}
+
+	for {
+		committed, createdFile, err := uploadChunkWithRetry(httpClient, policy, progressReader, sessionURI, offset)
+		if err != nil {
+			next, ok := rotateAfterQuotaError(rotator, progressReader, account, err)
+			if !ok {
+				return nil, err // State is kept so the next run can resume.
+			}
+			// Bytes committed so far belong to the exhausted account's
+			// session, so the next account sends the file from the start.
+			account = next
+			if err := startSession(); err != nil {
+				return nil, err
+			}
+			offset = 0
+			state.SessionURI, state.Offset = sessionURI, 0
+			_ = updateResumeState(stateKey, state)
+			continue
+		}
+		if createdFile != nil {
+			_ = updateResumeState(stateKey, nil)
+			return createdFile, nil
+		}
+		if committed <= offset && offset < size {
+			return nil, fmt.Errorf("upload made no progress at offset %d", offset)
+		}
+		offset = committed
+		state.Offset = offset
+		_ = updateResumeState(stateKey, state)
+	}
+}
+
+// uploadChunkWithRetry sends the chunk starting at offset and returns the
+// committed offset, retrying transient failures under policy.
+// Before a retry Drive is asked how much it has committed, so the upload
+// continues from the last committed byte instead of restarting the chunk.
+func uploadChunkWithRetry(httpClient *http.Client, policy RetryPolicy, progressReader *ProgressTrackingFileReader, sessionURI string, offset int64) (int64, *drive.File, error) {
+	size := progressReader.Size
+	var committed int64
+	var createdFile *drive.File
+	resync := false
+	err := policy.Do("upload of "+progressReader.FileName, progressReader.warn, func() error {
+		var err error
+		if resync {
+			committed, createdFile, err = queryUploadStatus(httpClient, sessionURI, size)
+			if err != nil || createdFile != nil {
+				return err
+			}
+			offset = committed
+		}
+		resync = true
+
+		if err := progressReader.SeekTo(offset); err != nil {
+			return err
+		}
+		length := min(int64(UPLOAD_CHUNK_SIZE), size-offset)
+		committed, createdFile, err = sendUploadChunk(httpClient, sessionURI, io.LimitReader(progressReader, length), offset, length, size)
+		return err
+	})
+	return committed, createdFile, err
+}
+
+// DeleteDriveFile permanently deletes a file from Google Drive by its ID,
+// skipping the trash. Prefer TrashDriveFile unless this is really intended.
+// A retry that finds the file gone means an earlier attempt deleted it and
+// only its response was lost, so that counts as success.
 func DeleteDriveFile(svc *drive.Service, fileID string) error {
-	err := svc.Files.Delete(fileID).Do()
+	attempt := 0
+	err := DefaultRetryPolicy.Do("deletion of "+fileID, nil, func() error {
+		attempt++
+		err := svc.Files.Delete(fileID).SupportsAllDrives(true).Do()
+		if attempt > 1 && isNotFound(err) {
+			return nil
+		}
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete file '%s' from Google Drive: %w", fileID, err)
 	}
@@ -125,7 +438,9 @@ func DeleteDriveFile(svc *drive.Service, fileID string) error {
 }
 
 // driveIdRegex for extracting file ID from various GDrive link formats.
-var driveIdRegex = regexp.MustCompile(`(?:(?:https?:\/\/drive\.google\.com\/(?:file\/d\/|open\?id=|drive\/folders\/|folderview\?id=))|(?:\b))([a-zA-Z0-9_-]{25,})(?:\b|\?|$)`)
+// File and folder IDs are 25+ characters; Shared Drive root IDs are 19
+// characters starting with "0A".
+var driveIdRegex = regexp.MustCompile(`(?:(?:https?:\/\/drive\.google\.com\/(?:file\/d\/|open\?id=|drive\/folders\/|folderview\?id=))|(?:\b))([a-zA-Z0-9_-]{25,}|0A[a-zA-Z0-9_-]{17})(?:\b|\?|$)`)
 
 // ExtractFileID extracts the Google Drive file ID from a string (which can be an ID or a link).
 func ExtractFileID(idOrLink string) (string, error) {
@@ -136,9 +451,23 @@ func ExtractFileID(idOrLink string) (string, error) {
 	// If no regex match, assume the input itself might be an ID.
 	// Basic validation for typical GDrive ID characters and length.
 	// This regex is simpler than the above, just for validating a potential raw ID.
-	rawIdRegex := regexp.MustCompile(`^[a-zA-Z0-9_-]{25,}$`)
+	rawIdRegex := regexp.MustCompile(`^(?:[a-zA-Z0-9_-]{25,}|0A[a-zA-Z0-9_-]{17})$`)
 	if rawIdRegex.MatchString(idOrLink) {
 		return idOrLink, nil
 	}
 	return "", fmt.Errorf("invalid or unextractable Google Drive ID/link format: %s", idOrLink)
 }
+
+// LocalFileMD5 returns the hex MD5 checksum of a local file.
+func LocalFileMD5(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %w", filePath, err)
+	}
+	defer file.Close()
+	hasher := md5.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", filePath, err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,85 @@
+// File: penguindex-go/internal/gdrive/list.go
+package gdrive
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// listFields is the per-file field list requested by ListFolder.
+const listFields = "nextPageToken, files(id, name, mimeType, size, md5Checksum, createdTime, modifiedTime, webViewLink, parents, driveId)"
+
+// ListFolder returns every non-trashed item directly inside folderID,
+// following pagination. Items in Shared Drives are included.
+func ListFolder(svc *drive.Service, folderID string) ([]*drive.File, error) {
+	query := fmt.Sprintf("'%s' in parents and trashed = false", escapeQueryString(folderID))
+
+	var files []*drive.File
+	err := DefaultRetryPolicy.Do("listing of folder "+folderID, nil, func() error {
+		files = nil
+		return allDrives(svc.Files.List()).
+			Q(query).
+			PageSize(1000).
+			Fields(listFields).
+			Pages(context.Background(), func(page *drive.FileList) error {
+				files = append(files, page.Files...)
+				return nil
+			})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folder '%s': %w", folderID, err)
+	}
+	return files, nil
+}
+
+// allDrives makes a Files.List call search My Drive, shared-with-me items and
+// every Shared Drive the account is a member of.
+func allDrives(call *drive.FilesListCall) *drive.FilesListCall {
+	return call.SupportsAllDrives(true).IncludeItemsFromAllDrives(true).Corpora("allDrives")
+}
+
+// IsFolder reports whether file is a Drive folder.
+func IsFolder(file *drive.File) bool {
+	return file.MimeType == FolderMimeType
+}
+
+// SearchScope selects the corpus SearchFiles looks in. The zero value is
+// corpora=user: My Drive and items shared with the account, but no Shared
+// Drive contents, so a broad query cannot reach into every Shared Drive.
+type SearchScope struct {
+	DriveID   string // Search only this Shared Drive
+	AllDrives bool   // Search My Drive and every Shared Drive the account is a member of
+}
+
+// SearchFiles returns every non-trashed item matching a Drive search query
+// (the Files.List q syntax, e.g. "name contains 'tmp'") within scope,
+// following pagination.
+func SearchFiles(svc *drive.Service, query string, scope SearchScope) ([]*drive.File, error) {
+	var files []*drive.File
+	err := DefaultRetryPolicy.Do("search", nil, func() error {
+		files = nil
+		call := svc.Files.List().SupportsAllDrives(true).IncludeItemsFromAllDrives(true)
+		switch {
+		case scope.DriveID != "":
+			call = call.Corpora("drive").DriveId(scope.DriveID)
+		case scope.AllDrives:
+			call = call.Corpora("allDrives")
+		default:
+			call = call.Corpora("user")
+		}
+		return call.
+			Q(fmt.Sprintf("(%s) and trashed = false", query)).
+			PageSize(1000).
+			Fields(listFields).
+			Pages(context.Background(), func(page *drive.FileList) error {
+				files = append(files, page.Files...)
+				return nil
+			})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search files with query %q: %w", query, err)
+	}
+	return files, nil
+}
@@ -0,0 +1,165 @@
+// File: penguindex-go/internal/gdrive/pathcache.go
+package gdrive
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"github.com/fatih/color"
+)
+
+const (
+	FOLDER_PATH_CACHE_FILE = "folder_paths.json"
+	FOLDER_PATH_CACHE_TTL  = 24 * time.Hour
+)
+
+// cachedFolder is one resolved path -> folder ID mapping.
+type cachedFolder struct {
+	ID       string    `json:"id"`
+	CachedAt time.Time `json:"cached_at"`
+}
+
+var folderPathCacheMu sync.Mutex
+
+func folderPathCachePath() (string, error) {
+	dir, err := utils.AppCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FOLDER_PATH_CACHE_FILE), nil
+}
+
+func folderPathCacheKey(rootID string, components []string) string {
+	return rootID + "/" + strings.Join(components, "/")
+}
+
+// lookupCachedFolder returns the cached folder ID for key if it has not expired.
+func lookupCachedFolder(key string) (string, bool) {
+	folderPathCacheMu.Lock()
+	defer folderPathCacheMu.Unlock()
+
+	path, err := folderPathCachePath()
+	if err != nil {
+		return "", false
+	}
+	entries := map[string]cachedFolder{}
+	if err := utils.ReadJSONFile(path, &entries); err != nil {
+		return "", false
+	}
+	entry, ok := entries[key]
+	if !ok || time.Since(entry.CachedAt) > FOLDER_PATH_CACHE_TTL {
+		return "", false
+	}
+	return entry.ID, true
+}
+
+// storeCachedFolders records freshly resolved mappings and drops expired ones.
+func storeCachedFolders(resolved map[string]string) error {
+	folderPathCacheMu.Lock()
+	defer folderPathCacheMu.Unlock()
+
+	path, err := folderPathCachePath()
+	if err != nil {
+		return err
+	}
+	entries := map[string]cachedFolder{}
+	if err := utils.ReadJSONFile(path, &entries); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		entries = map[string]cachedFolder{} // A corrupt cache is simply rebuilt.
+	}
+	for key, entry := range entries {
+		if time.Since(entry.CachedAt) > FOLDER_PATH_CACHE_TTL {
+			delete(entries, key)
+		}
+	}
+	now := time.Now()
+	for key, id := range resolved {
+		entries[key] = cachedFolder{ID: id, CachedAt: now}
+	}
+	return utils.WriteJSONFile(path, entries)
+}
+
+// SplitFolderPath splits a slash-separated Drive path into its components,
+// trimming surrounding spaces and ignoring empty and "." segments. ".." is
+// rejected because Drive folders can have several parents.
+func SplitFolderPath(folderPath string) ([]string, error) {
+	var components []string
+	for _, part := range strings.Split(folderPath, "/") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			return nil, fmt.Errorf("'..' is not supported in Drive paths: %s", folderPath)
+		}
+		components = append(components, part)
+	}
+	return components, nil
+}
+
+// EnsureFolderPath resolves folderPath relative to rootID, creating every
+// missing component (like mkdir -p), and returns the ID of the last folder.
+// Existing folders are reused so repeated calls never create duplicates.
+// Resolved mappings are cached locally for FOLDER_PATH_CACHE_TTL; a cached
+// leaf is re-checked with a single lookup before it is trusted.
+func EnsureFolderPath(svc *drive.Service, rootID, folderPath string) (string, error) {
+	components, err := SplitFolderPath(folderPath)
+	if err != nil {
+		return "", err
+	}
+	if len(components) == 0 {
+		return rootID, nil
+	}
+
+	if cachedID, ok := lookupCachedFolder(folderPathCacheKey(rootID, components)); ok && cachedLeafValid(svc, cachedID, rootID, components) {
+		return cachedID, nil
+	}
+	// No entry, or a stale one: walk the path again.
+
+	resolved := map[string]string{}
+	parentID := rootID
+	for i, name := range components {
+		folder, created, err := FindOrCreateFolder(svc, name, parentID)
+		if err != nil {
+			return "", err
+		}
+		if created {
+			utils.Status(color.CyanString("Created folder: %s (%s)", strings.Join(components[:i+1], "/"), folder.Id))
+		}
+		parentID = folder.Id
+		resolved[folderPathCacheKey(rootID, components[:i+1])] = folder.Id
+	}
+
+	if err := storeCachedFolders(resolved); err != nil {
+		utils.Warn(color.YellowString("Warning: could not update folder path cache: %v", err))
+	}
+	return parentID, nil
+}
+
+// cachedLeafValid re-checks a cached folder ID for components: it must still be
+// a non-trashed folder with the last component's name, directly inside the
+// folder the cache holds for the parent path (or rootID). A folder that was
+// renamed or moved since it was cached would otherwise receive the upload.
+func cachedLeafValid(svc *drive.Service, cachedID, rootID string, components []string) bool {
+	parentID := rootID
+	if len(components) > 1 {
+		var ok bool
+		if parentID, ok = lookupCachedFolder(folderPathCacheKey(rootID, components[:len(components)-1])); !ok {
+			return false
+		}
+	}
+	folder, err := retryCall("lookup of folder "+cachedID, func() (*drive.File, error) {
+		return svc.Files.Get(cachedID).SupportsAllDrives(true).Fields("id", "name", "mimeType", "parents", "trashed").Do()
+	})
+	if err != nil || folder.Trashed || folder.MimeType != FolderMimeType || folder.Name != components[len(components)-1] {
+		return false
+	}
+	return slices.Contains(folder.Parents, parentID)
+}
@@ -0,0 +1,41 @@
+// File: penguindex-go/internal/gdrive/pathcache_test.go
+package gdrive
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitFolderPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "", want: nil},
+		{path: "/", want: nil},
+		{path: "Backups", want: []string{"Backups"}},
+		{path: "Backups/2024/June", want: []string{"Backups", "2024", "June"}},
+		{path: "/Backups/2024/", want: []string{"Backups", "2024"}},
+		{path: "Backups//2024", want: []string{"Backups", "2024"}},
+		{path: "./Backups/./2024/.", want: []string{"Backups", "2024"}},
+		{path: " Backups / 2024 ", want: []string{"Backups", "2024"}},
+		{path: " / . / ", want: nil},
+		{path: "My Photos/Summer Trip", want: []string{"My Photos", "Summer Trip"}},
+		{path: "..", wantErr: true},
+		{path: "Backups/../Other", wantErr: true},
+		{path: "Backups/ .. ", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SplitFolderPath(tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("SplitFolderPath(%q) = %q, want an error", tt.path, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitFolderPath(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// File: penguindex-go/internal/gdrive/progress.go
+package gdrive
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"github.com/schollz/progressbar/v3"
+	"github.com/fatih/color"
+)
+
+// MultiProgress is an aggregate progress bar shared by concurrent transfers.
+// Individual transfers report byte deltas and print status lines through it,
+// so their output does not tear the bar.
+type MultiProgress struct {
+	mu    sync.Mutex
+	bar   *progressbar.ProgressBar
+	done  int64
+	total int64
+}
+
+// NewMultiProgress creates an aggregate bar over totalBytes for fileCount files.
+func NewMultiProgress(totalBytes int64, fileCount int) *MultiProgress {
+	bar := newTransferBar(max(totalBytes, 1), fmt.Sprintf("Total (%d files)", fileCount)) // progressbar refuses a zero max
+	return &MultiProgress{bar: bar, total: totalBytes}
+}
+
+// newTransferBar creates the byte progress bar used for uploads and downloads.
+func newTransferBar(size int64, description string) *progressbar.ProgressBar {
+	return progressbar.NewOptions64(
+		size,
+		progressbar.OptionSetWriter(utils.ProgressWriter()),
+		progressbar.OptionEnableColorCodes(true),
+		progressbar.OptionShowBytes(true),
+		progressbar.OptionSetWidth(30),
+		progressbar.OptionSetDescription(color.CyanString(description)),
+		progressbar.OptionSetTheme(progressbar.Theme{
+			Saucer:        color.GreenString("="),
+			SaucerHead:    color.GreenString(">"),
+			SaucerPadding: " ",
+			BarStart:      "[",
+			BarEnd:        "]",
+		}),
+		progressbar.OptionThrottle(100*time.Millisecond), // Update progress bar less frequently
+	)
+}
+
+// finishBarLine moves output past a finished bar, which is not drawn with -quiet.
+func finishBarLine() {
+	if !utils.Quiet {
+		fmt.Println()
+	}
+}
+
+// Add moves the aggregate bar by delta bytes; delta is negative when a
+// transfer rewinds to re-send a range.
+func (m *MultiProgress) Add(delta int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.done += delta
+	_ = m.bar.Set64(m.done)
+}
+
+// Println prints a status line above the aggregate bar, unless -quiet is set.
+func (m *MultiProgress) Println(format string, a ...any) {
+	if utils.Quiet {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_ = m.bar.Clear()
+	fmt.Printf(format+"\n", a...)
+	_ = m.bar.RenderBlank()
+}
+
+// Warn prints a warning on stderr without tearing the aggregate bar.
+func (m *MultiProgress) Warn(line string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_ = m.bar.Clear()
+	utils.Warn(line)
+	_ = m.bar.RenderBlank()
+}
+
+// Finish completes the aggregate bar and moves output to a fresh line.
+func (m *MultiProgress) Finish() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_ = m.bar.Finish()
+	finishBarLine()
+}
+
+// fileMilestones tracks which quarter-way points of a file have been announced.
+type fileMilestones struct {
+	shared *MultiProgress
+	name   string
+	size   int64
+	next   int // Next percentage to announce
+}
+
+func (f *fileMilestones) update(pos int64) {
+	if f.size == 0 {
+		return
+	}
+	for f.next < 100 && pos*100 >= int64(f.next)*f.size {
+		f.shared.Println("  %s: %d%% (%s of %s)", f.name, f.next,
+			utils.HumanReadableSize(uint64(pos)), utils.HumanReadableSize(uint64(f.size)))
+		f.next += 25
+	}
+}
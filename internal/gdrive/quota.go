@@ -0,0 +1,96 @@
+// File: penguindex-go/internal/gdrive/quota.go
+package gdrive
+
+import (
+	"errors"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	"github.com/fatih/color"
+)
+
+// AccountRotator is implemented by the transport of a client backed by
+// several service accounts (auth.Pool). Uploads use it to continue with the
+// next account once the current one runs out of upload quota.
+type AccountRotator interface {
+	CurrentAccount() string
+	SpareAccounts() int
+	RotateAccount(exhausted string) (next string, ok bool)
+}
+
+// quotaReasons are 403 reasons returned once an account has used up its
+// daily upload allowance (750 GB) or its storage.
+var quotaReasons = map[string]bool{
+	"storageQuotaExceeded": true,
+	"uploadLimitExceeded":  true,
+	"dailyLimitExceeded":   true,
+}
+
+// rateLimitReason is what Drive returns when an account hits the 750 GB
+// daily cap, but also for short per-second limits. It only counts as a
+// quota error once backoff has failed to clear it.
+const rateLimitReason = "userRateLimitExceeded"
+
+// IsQuotaError reports whether err is a per-account upload quota error,
+// including userRateLimitExceeded left over after retries ran out.
+func IsQuotaError(err error) bool {
+	return hasForbiddenReason(err, func(reason string) bool {
+		return quotaReasons[reason] || reason == rateLimitReason
+	})
+}
+
+// isHardQuotaError reports whether err is a quota error that retrying on
+// the same account cannot clear.
+func isHardQuotaError(err error) bool {
+	return hasForbiddenReason(err, func(reason string) bool { return quotaReasons[reason] })
+}
+
+// hasForbiddenReason reports whether err is a 403 with a reason matching match.
+func hasForbiddenReason(err error, match func(reason string) bool) bool {
+	var gErr *googleapi.Error
+	if !errors.As(err, &gErr) || gErr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, item := range gErr.Errors {
+		if match(item.Reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// accountRotator returns the rotator behind httpClient, or nil.
+func accountRotator(httpClient *http.Client) AccountRotator {
+	rotator, _ := httpClient.Transport.(AccountRotator)
+	return rotator
+}
+
+// uploadRetryPolicy is DefaultRetryPolicy, except that hard quota errors are
+// not waited out while another account can take over. userRateLimitExceeded
+// is still retried first, so a short rate limit does not cost an account.
+func uploadRetryPolicy(rotator AccountRotator) RetryPolicy {
+	policy := DefaultRetryPolicy
+	if rotator != nil {
+		policy.GiveUp = func(err error) bool {
+			return isHardQuotaError(err) && rotator.SpareAccounts() > 0
+		}
+	}
+	return policy
+}
+
+// rotateAfterQuotaError switches to the next account if err is a quota error
+// for account and another account is left, and returns the new account. It
+// runs once the retry policy has given up, so userRateLimitExceeded only
+// rotates after backoff on the same account did not help.
+func rotateAfterQuotaError(rotator AccountRotator, progressReader *ProgressTrackingFileReader, account string, err error) (string, bool) {
+	if rotator == nil || !IsQuotaError(err) {
+		return "", false
+	}
+	next, ok := rotator.RotateAccount(account)
+	if !ok {
+		progressReader.warn(color.RedString("Upload quota reached for %s and no other service account has quota left today.", account))
+		return "", false
+	}
+	progressReader.warn(color.YellowString("Upload quota reached for %s, continuing %s as %s", account, progressReader.FileName, next))
+	return next, true
+}
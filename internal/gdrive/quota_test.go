@@ -0,0 +1,101 @@
+package gdrive
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"google.golang.org/api/googleapi"
+)
+
+// fakeRotator is an AccountRotator with a fixed number of spare accounts.
+type fakeRotator struct{ spare int }
+
+func (r *fakeRotator) CurrentAccount() string { return "a" }
+func (r *fakeRotator) SpareAccounts() int     { return r.spare }
+func (r *fakeRotator) RotateAccount(string) (string, bool) {
+	if r.spare == 0 {
+		return "", false
+	}
+	r.spare--
+	return "b", true
+}
+
+func TestIsQuotaError(t *testing.T) {
+	forbidden := func(reason string) error {
+		return &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: reason}}}
+	}
+	tests := []struct {
+		name      string
+		err       error
+		wantQuota bool
+		wantHard  bool
+	}{
+		{"storage quota", forbidden("storageQuotaExceeded"), true, true},
+		{"upload limit", forbidden("uploadLimitExceeded"), true, true},
+		{"daily limit", forbidden("dailyLimitExceeded"), true, true},
+		{"user rate limit", forbidden("userRateLimitExceeded"), true, false},
+		{"wrapped", fmt.Errorf("upload failed: %w", forbidden("uploadLimitExceeded")), true, true},
+		{"rate limit", forbidden("rateLimitExceeded"), false, false},
+		{"permission", forbidden("insufficientFilePermissions"), false, false},
+		{"429", &googleapi.Error{Code: http.StatusTooManyRequests, Errors: []googleapi.ErrorItem{{Reason: "userRateLimitExceeded"}}}, false, false},
+		{"plain error", fmt.Errorf("storageQuotaExceeded"), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsQuotaError(tt.err); got != tt.wantQuota {
+				t.Errorf("IsQuotaError = %v, want %v", got, tt.wantQuota)
+			}
+			if got := isHardQuotaError(tt.err); got != tt.wantHard {
+				t.Errorf("isHardQuotaError = %v, want %v", got, tt.wantHard)
+			}
+		})
+	}
+}
+
+func TestUploadRetryPolicyGiveUp(t *testing.T) {
+	forbidden := func(reason string) error {
+		return &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: reason}}}
+	}
+	tests := []struct {
+		name       string
+		spare      int
+		err        error
+		wantGiveUp bool
+	}{
+		{"quota with spare account", 1, forbidden("uploadLimitExceeded"), true},
+		{"quota without spare account", 0, forbidden("uploadLimitExceeded"), false},
+		{"rate limit is waited out first", 1, forbidden("userRateLimitExceeded"), false},
+		{"server error", 1, &googleapi.Error{Code: http.StatusServiceUnavailable}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := uploadRetryPolicy(&fakeRotator{spare: tt.spare})
+			if got := policy.GiveUp(tt.err); got != tt.wantGiveUp {
+				t.Errorf("GiveUp = %v, want %v", got, tt.wantGiveUp)
+			}
+		})
+	}
+	if policy := uploadRetryPolicy(nil); policy.GiveUp != nil {
+		t.Error("uploadRetryPolicy(nil) set GiveUp without a rotator")
+	}
+}
+
+func TestRotateAfterQuotaError(t *testing.T) {
+	rateLimited := &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "userRateLimitExceeded"}}}
+	reader := &ProgressTrackingFileReader{FileName: "x.bin"}
+
+	// Retries ran out on a rate limit: continue as the spare account.
+	if next, ok := rotateAfterQuotaError(&fakeRotator{spare: 1}, reader, "a", rateLimited); !ok || next != "b" {
+		t.Errorf("rotateAfterQuotaError = %q, %v; want b, true", next, ok)
+	}
+	if _, ok := rotateAfterQuotaError(&fakeRotator{spare: 0}, reader, "a", rateLimited); ok {
+		t.Error("rotateAfterQuotaError rotated without a spare account")
+	}
+	if _, ok := rotateAfterQuotaError(&fakeRotator{spare: 1}, reader, "a", &googleapi.Error{Code: http.StatusNotFound}); ok {
+		t.Error("rotateAfterQuotaError rotated on a 404")
+	}
+	if _, ok := rotateAfterQuotaError(nil, reader, "a", rateLimited); ok {
+		t.Error("rotateAfterQuotaError rotated without a rotator")
+	}
+}
@@ -0,0 +1,237 @@
+// File: penguindex-go/internal/gdrive/resumable.go
+package gdrive
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Drive's resumable upload protocol requires chunk sizes that are multiples of 256 KiB.
+const (
+	RESUMABLE_UPLOAD_URL = "https://www.googleapis.com/upload/drive/v3/files"
+	UPLOAD_CHUNK_SIZE    = 32 * 256 * 1024 // 8 MiB
+	RESUME_STATE_FILE    = "uploads.json"
+)
+
+// uploadFields is the field list requested for the created file on completion.
+const uploadFields = "id,name,mimeType,size,md5Checksum,createdTime,webViewLink,webContentLink,parents"
+
+// ResumeState is the persisted record of an in-progress resumable upload.
+// The fingerprint (path, size, mtime) guards against resuming a file that
+// changed since the session was started.
+type ResumeState struct {
+	SessionURI string    `json:"session_uri"`
+	FilePath   string    `json:"file_path"`
+	FolderID   string    `json:"folder_id"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+	Offset     int64     `json:"offset"` // Bytes Drive has confirmed as committed
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// resumeStateMu serialises read-modify-write cycles on the state file within this process.
+var resumeStateMu sync.Mutex
+
+// resumeStateKey identifies an upload by its destination and local path.
+// Content updates of an existing file are keyed by that file's ID instead.
+func resumeStateKey(absPath, folderID, existingFileID string) string {
+	if existingFileID != "" {
+		return "update:" + existingFileID + ":" + absPath
+	}
+	return folderID + ":" + absPath
+}
+
+func resumeStatePath() (string, error) {
+	dir, err := utils.AppCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, RESUME_STATE_FILE), nil
+}
+
+func loadResumeStates() (map[string]ResumeState, error) {
+	path, err := resumeStatePath()
+	if err != nil {
+		return nil, err
+	}
+	states := map[string]ResumeState{}
+	if err := utils.ReadJSONFile(path, &states); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return states, nil
+}
+
+// updateResumeState stores state under key, or removes the entry if state is nil.
+func updateResumeState(key string, state *ResumeState) error {
+	resumeStateMu.Lock()
+	defer resumeStateMu.Unlock()
+
+	states, err := loadResumeStates()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		if _, ok := states[key]; !ok {
+			return nil
+		}
+		delete(states, key)
+	} else {
+		state.UpdatedAt = time.Now()
+		states[key] = *state
+	}
+	path, err := resumeStatePath()
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSONFile(path, states)
+}
+
+// lookupResumeState returns the saved state for key if it still matches the file on disk.
+func lookupResumeState(key string, fileInfo os.FileInfo) *ResumeState {
+	resumeStateMu.Lock()
+	states, err := loadResumeStates()
+	resumeStateMu.Unlock()
+	if err != nil {
+		return nil
+	}
+	state, ok := states[key]
+	if !ok || state.Size != fileInfo.Size() || !state.ModTime.Equal(fileInfo.ModTime()) {
+		return nil
+	}
+	return &state
+}
+
+// startResumableSession initiates a resumable upload and returns the session URI.
+// If existingFileID is set, the session replaces that file's content in place
+// (keeping its ID and shared links) instead of creating a new file.
+func startResumableSession(httpClient *http.Client, metadata *drive.File, size int64, existingFileID string) (string, error) {
+	body, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode file metadata: %w", err)
+	}
+
+	method, initURL := http.MethodPost, RESUMABLE_UPLOAD_URL
+	if existingFileID != "" {
+		method, initURL = http.MethodPatch, RESUMABLE_UPLOAD_URL+"/"+url.PathEscape(existingFileID)
+	}
+	initURL += "?uploadType=resumable&supportsAllDrives=true&fields=" + url.QueryEscape(uploadFields)
+	req, err := http.NewRequest(method, initURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Type", metadata.MimeType)
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to start resumable upload session: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return "", fmt.Errorf("failed to start resumable upload session: %w", err)
+	}
+	sessionURI := resp.Header.Get("Location")
+	if sessionURI == "" {
+		return "", fmt.Errorf("resumable upload session response did not include a Location header")
+	}
+	return sessionURI, nil
+}
+
+// queryUploadStatus asks Drive how many bytes of the session are committed.
+// If the upload already completed, the created file is returned instead.
+func queryUploadStatus(httpClient *http.Client, sessionURI string, size int64) (int64, *drive.File, error) {
+	req, err := http.NewRequest(http.MethodPut, sessionURI, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.ContentLength = 0
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to query upload status: %w", err)
+	}
+	defer resp.Body.Close()
+	return parseUploadResponse(resp)
+}
+
+// sendUploadChunk uploads length bytes from body starting at offset and
+// returns the committed offset reported by Drive.
+func sendUploadChunk(httpClient *http.Client, sessionURI string, body io.Reader, offset, length, size int64) (int64, *drive.File, error) {
+	req, err := http.NewRequest(http.MethodPut, sessionURI, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.ContentLength = length
+	if length == 0 {
+		// Zero-byte files finish with an empty range.
+		req.Body = http.NoBody
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+	} else {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, size))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to upload chunk at offset %d: %w", offset, err)
+	}
+	defer resp.Body.Close()
+	return parseUploadResponse(resp)
+}
+
+// parseUploadResponse interprets a response from a resumable session URI.
+// 308 means "resume incomplete" with the committed range in the Range header;
+// 200/201 means the upload finished and the body is the created file.
+func parseUploadResponse(resp *http.Response) (int64, *drive.File, error) {
+	if resp.StatusCode == http.StatusPermanentRedirect {
+		return parseCommittedRange(resp.Header.Get("Range"))
+	}
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return 0, nil, err
+	}
+	var createdFile drive.File
+	if err := json.NewDecoder(resp.Body).Decode(&createdFile); err != nil {
+		return 0, nil, fmt.Errorf("failed to decode uploaded file metadata: %w", err)
+	}
+	return createdFile.Size, &createdFile, nil
+}
+
+// parseCommittedRange converts a "bytes=0-N" Range header to the next offset (N+1).
+// A missing header means nothing has been committed yet.
+func parseCommittedRange(rangeHeader string) (int64, *drive.File, error) {
+	if rangeHeader == "" {
+		return 0, nil, nil
+	}
+	_, end, ok := strings.Cut(strings.TrimPrefix(rangeHeader, "bytes="), "-")
+	if !ok {
+		return 0, nil, fmt.Errorf("unexpected Range header in upload response: %q", rangeHeader)
+	}
+	last, err := strconv.ParseInt(end, 10, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unexpected Range header in upload response: %q", rangeHeader)
+	}
+	return last + 1, nil, nil
+}
+
+// isSessionGone reports whether err means the resumable session expired or was discarded.
+func isSessionGone(err error) bool {
+	var gErr *googleapi.Error
+	return errors.As(err, &gErr) && (gErr.Code == http.StatusNotFound || gErr.Code == http.StatusGone)
+}
@@ -0,0 +1,95 @@
+// File: penguindex-go/internal/gdrive/resumable_test.go
+package gdrive
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseCommittedRange(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    int64
+		wantErr bool
+	}{
+		{header: "", want: 0},
+		{header: "bytes=0-0", want: 1},
+		{header: "bytes=0-262143", want: 262144},
+		{header: "bytes=0-5368709119", want: 5368709120},
+		{header: "bytes=0", wantErr: true},
+		{header: "bytes=0-", wantErr: true},
+		{header: "bytes=0-abc", wantErr: true},
+		{header: "garbage", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, file, err := parseCommittedRange(tt.header)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCommittedRange(%q) = %d, want an error", tt.header, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want || file != nil {
+			t.Errorf("parseCommittedRange(%q) = %d, %v, %v; want %d", tt.header, got, file, err, tt.want)
+		}
+	}
+}
+
+func TestParseUploadResponse(t *testing.T) {
+	response := func(status int, rangeHeader, body string) *http.Response {
+		header := http.Header{}
+		if rangeHeader != "" {
+			header.Set("Range", rangeHeader)
+		}
+		return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+	}
+
+	tests := []struct {
+		name       string
+		resp       *http.Response
+		wantOffset int64
+		wantFileID string
+		wantErr    bool
+	}{
+		{name: "incomplete", resp: response(http.StatusPermanentRedirect, "bytes=0-1023", ""), wantOffset: 1024},
+		{name: "nothing committed", resp: response(http.StatusPermanentRedirect, "", ""), wantOffset: 0},
+		{name: "created", resp: response(http.StatusCreated, "", `{"id":"abc","size":"2048"}`), wantOffset: 2048, wantFileID: "abc"},
+		{name: "updated", resp: response(http.StatusOK, "", `{"id":"def","size":"10"}`), wantOffset: 10, wantFileID: "def"},
+		{name: "bad metadata", resp: response(http.StatusOK, "", `not json`), wantErr: true},
+		{name: "session gone", resp: response(http.StatusNotFound, "", `{"error":{"code":404,"message":"gone"}}`), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, file, err := parseUploadResponse(tt.resp)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseUploadResponse = %d, %v; want an error", offset, file)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseUploadResponse: %v", err)
+			}
+			if offset != tt.wantOffset {
+				t.Errorf("offset = %d, want %d", offset, tt.wantOffset)
+			}
+			switch {
+			case tt.wantFileID == "" && file != nil:
+				t.Errorf("file = %+v, want nil", file)
+			case tt.wantFileID != "" && (file == nil || file.Id != tt.wantFileID):
+				t.Errorf("file = %+v, want ID %q", file, tt.wantFileID)
+			}
+		})
+	}
+}
+
+func TestIsSessionGone(t *testing.T) {
+	for status, want := range map[int]bool{http.StatusNotFound: true, http.StatusGone: true, http.StatusForbidden: false, http.StatusInternalServerError: false} {
+		_, _, err := parseUploadResponse(&http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))})
+		if got := isSessionGone(err); got != want {
+			t.Errorf("isSessionGone(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
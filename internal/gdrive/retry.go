@@ -0,0 +1,159 @@
+// File: penguindex-go/internal/gdrive/retry.go
+package gdrive
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/googleapi"
+	"github.com/fatih/color"
+)
+
+// RetryPolicy is a truncated, jittered exponential backoff for transient
+// Drive errors, as recommended by Google's API guidelines.
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first one
+	BaseDelay   time.Duration // Delay before the first retry, doubled for each later one
+	MaxDelay    time.Duration // Cap for the computed delay and for Retry-After
+	// GiveUp, if set, reports transient errors that should be returned at
+	// once instead, e.g. quota errors another account can take over.
+	GiveUp func(err error) bool
+}
+
+// DefaultRetryPolicy is used for every Drive call.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 6,
+	BaseDelay:   1 * time.Second,
+	MaxDelay:    64 * time.Second,
+}
+
+// retryableReasons are 403 error reasons Google documents as transient.
+var retryableReasons = map[string]bool{
+	"userRateLimitExceeded": true,
+	"rateLimitExceeded":     true,
+	"backendError":          true,
+	"internalError":         true,
+}
+
+// Retryable reports whether err is transient: a 429 or 5xx response, a 403
+// rate-limit response, a dropped or refused connection, a timeout or a
+// temporary DNS failure. retryAfter is the delay the
+// server asked for in its Retry-After header, or 0.
+func Retryable(err error) (retry bool, retryAfter time.Duration) {
+	var gErr *googleapi.Error
+	if errors.As(err, &gErr) {
+		switch {
+		case gErr.Code == http.StatusTooManyRequests, gErr.Code >= 500:
+			retry = true
+		case gErr.Code == http.StatusForbidden:
+			for _, item := range gErr.Errors {
+				if retryableReasons[item.Reason] {
+					retry = true
+				}
+			}
+		}
+		if retry {
+			retryAfter = parseRetryAfter(gErr.Header.Get("Retry-After"))
+		}
+		return retry, retryAfter
+	}
+
+	// Only look inside transport errors: a url.Error also wraps permanent
+	// failures such as an OAuth token refusal, which must not be retried.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	// Other *net.OpError failures, such as "no such host" or an unreachable
+	// network, are permanent and fail at once.
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout(),
+		errors.As(err, &dnsErr) && dnsErr.IsTemporary,
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EPIPE):
+		return true, 0
+	}
+	return false, 0
+}
+
+// isNotFound reports whether err is a 404 response from Drive.
+func isNotFound(err error) bool {
+	var gErr *googleapi.Error
+	return errors.As(err, &gErr) && gErr.Code == http.StatusNotFound
+}
+
+// parseRetryAfter accepts both forms of the header: seconds or an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		return max(time.Until(when), 0)
+	}
+	return 0
+}
+
+// Backoff returns how long to wait before retry number attempt (1-based):
+// the server's Retry-After if given, otherwise BaseDelay*2^(attempt-1) with
+// the upper half randomised, both capped at MaxDelay.
+func (p RetryPolicy) Backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, p.MaxDelay)
+	}
+	delay := p.MaxDelay
+	if attempt < 32 {
+		delay = min(p.BaseDelay<<(attempt-1), p.MaxDelay)
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// Do runs call until it succeeds, fails with a permanent error, or
+// MaxAttempts is reached. Each retry is logged through logf, which defaults
+// to a warning on stderr so it never mixes with -json output on stdout; desc
+// names the operation in that message.
+func (p RetryPolicy) Do(desc string, logf func(string), call func() error) error {
+	if logf == nil {
+		logf = utils.Warn
+	}
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil {
+			return nil
+		}
+		retry, retryAfter := Retryable(err)
+		if !retry || attempt >= p.MaxAttempts || (p.GiveUp != nil && p.GiveUp(err)) {
+			return err
+		}
+		delay := p.Backoff(attempt, retryAfter)
+		logf(color.YellowString("Retrying %s in %s (attempt %d/%d): %v", desc, delay.Round(100*time.Millisecond), attempt+1, p.MaxAttempts, err))
+		time.Sleep(delay)
+	}
+}
+
+// retryCall runs a Drive call returning a value under DefaultRetryPolicy.
+func retryCall[T any](desc string, call func() (T, error)) (T, error) {
+	var result T
+	err := DefaultRetryPolicy.Do(desc, nil, func() error {
+		var err error
+		result, err = call()
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,209 @@
+// File: penguindex-go/internal/gdrive/retry_test.go
+package gdrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+func TestRetryable(t *testing.T) {
+	apiError := func(code int, reason, retryAfter string) error {
+		gErr := &googleapi.Error{Code: code, Header: http.Header{}}
+		if reason != "" {
+			gErr.Errors = []googleapi.ErrorItem{{Reason: reason}}
+		}
+		if retryAfter != "" {
+			gErr.Header.Set("Retry-After", retryAfter)
+		}
+		return gErr
+	}
+	transportError := func(err error) error {
+		return &url.Error{Op: "Post", URL: "https://www.googleapis.com/upload/drive/v3/files", Err: err}
+	}
+
+	tests := []struct {
+		name           string
+		err            error
+		wantRetry      bool
+		wantRetryAfter time.Duration
+	}{
+		{"429", apiError(http.StatusTooManyRequests, "", ""), true, 0},
+		{"429 with Retry-After", apiError(http.StatusTooManyRequests, "", "7"), true, 7 * time.Second},
+		{"500", apiError(http.StatusInternalServerError, "", ""), true, 0},
+		{"503 with Retry-After", apiError(http.StatusServiceUnavailable, "", "3"), true, 3 * time.Second},
+		{"403 user rate limit", apiError(http.StatusForbidden, "userRateLimitExceeded", ""), true, 0},
+		{"403 rate limit", apiError(http.StatusForbidden, "rateLimitExceeded", ""), true, 0},
+		{"403 backend error", apiError(http.StatusForbidden, "backendError", ""), true, 0},
+		{"403 quota", apiError(http.StatusForbidden, "storageQuotaExceeded", ""), false, 0},
+		{"403 permission", apiError(http.StatusForbidden, "insufficientFilePermissions", "5"), false, 0},
+		{"404", apiError(http.StatusNotFound, "notFound", ""), false, 0},
+		{"wrapped 429", fmt.Errorf("upload failed: %w", apiError(http.StatusTooManyRequests, "", "")), true, 0},
+		{"EOF", io.EOF, true, 0},
+		{"unexpected EOF", transportError(io.ErrUnexpectedEOF), true, 0},
+		{"connection reset", transportError(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true, 0},
+		{"bare ECONNRESET", syscall.ECONNRESET, true, 0},
+		{"broken pipe", fmt.Errorf("write: %w", syscall.EPIPE), true, 0},
+		{"timeout", transportError(os.ErrDeadlineExceeded), true, 0},
+		{"connection refused", transportError(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), true, 0},
+		{"temporary DNS failure", transportError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "server misbehaving", Name: "www.googleapis.com", IsTemporary: true}}), true, 0},
+		{"DNS timeout", transportError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "i/o timeout", Name: "www.googleapis.com", IsTimeout: true}}), true, 0},
+		{"no such host", transportError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "www.googleapis.com", IsNotFound: true}}), false, 0},
+		{"network unreachable", transportError(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ENETUNREACH)}), false, 0},
+		{"OAuth refusal", transportError(errors.New(`oauth2: cannot fetch token: 400 Bad Request Response: {"error":"invalid_grant"}`)), false, 0},
+		{"plain error", errors.New("file not found"), false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retry, retryAfter := Retryable(tt.err)
+			if retry != tt.wantRetry || retryAfter != tt.wantRetryAfter {
+				t.Errorf("Retryable = %v, %s; want %v, %s", retry, retryAfter, tt.wantRetry, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"0", 0, 0},
+		{"-5", 0, 0},
+		{"120", 120 * time.Second, 120 * time.Second},
+		{"soon", 0, 0},
+		{"1.5", 0, 0},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+		// HTTP dates have second precision, so allow for truncation and test latency.
+		{time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat), 28 * time.Second, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %s, want between %s and %s", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 6, BaseDelay: time.Second, MaxDelay: 16 * time.Second}
+	tests := []struct {
+		attempt    int
+		retryAfter time.Duration
+		min, max   time.Duration
+	}{
+		{1, 0, 500 * time.Millisecond, time.Second},
+		{2, 0, time.Second, 2 * time.Second},
+		{3, 0, 2 * time.Second, 4 * time.Second},
+		{5, 0, 8 * time.Second, 16 * time.Second},
+		{6, 0, 8 * time.Second, 16 * time.Second},
+		{40, 0, 8 * time.Second, 16 * time.Second},
+		{1, 5 * time.Second, 5 * time.Second, 5 * time.Second},
+		{1, time.Hour, 16 * time.Second, 16 * time.Second},
+	}
+	for _, tt := range tests {
+		// The lower half is fixed and the upper half random, so sample repeatedly.
+		for i := 0; i < 50; i++ {
+			if got := policy.Backoff(tt.attempt, tt.retryAfter); got < tt.min || got > tt.max {
+				t.Errorf("Backoff(%d, %s) = %s, want between %s and %s", tt.attempt, tt.retryAfter, got, tt.min, tt.max)
+				break
+			}
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	transient := &googleapi.Error{Code: http.StatusServiceUnavailable}
+	permanent := &googleapi.Error{Code: http.StatusNotFound}
+
+	tests := []struct {
+		name         string
+		errs         []error // Returned by successive calls; nil after the list ends
+		giveUp       func(error) bool
+		wantErr      error
+		wantAttempts int
+	}{
+		{name: "success", wantAttempts: 1},
+		{name: "recovers", errs: []error{transient, transient}, wantAttempts: 3},
+		{name: "exhausted", errs: []error{transient, transient, transient, transient}, wantErr: transient, wantAttempts: 3},
+		{name: "permanent", errs: []error{permanent}, wantErr: permanent, wantAttempts: 1},
+		{name: "gives up", errs: []error{transient}, giveUp: func(error) bool { return true }, wantErr: transient, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := policy
+			p.GiveUp = tt.giveUp
+			var attempts, logged int
+			err := p.Do("test call", func(string) { logged++ }, func() error {
+				attempts++
+				if attempts <= len(tt.errs) {
+					return tt.errs[attempts-1]
+				}
+				return nil
+			})
+			if err != tt.wantErr {
+				t.Errorf("Do error = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts || logged != attempts-1 {
+				t.Errorf("Do made %d attempts with %d retry notices, want %d attempts", attempts, logged, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestDeleteDriveFileLostResponse(t *testing.T) {
+	saved := DefaultRetryPolicy
+	DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	defer func() { DefaultRetryPolicy = saved }()
+
+	tests := []struct {
+		name    string
+		dropped int // Requests whose connection is closed before a response
+		wantErr bool
+	}{
+		{name: "deleted, response lost", dropped: 1},
+		{name: "never existed", dropped: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= tt.dropped {
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error":{"code":404,"message":"File not found"}}`)
+			}))
+			defer server.Close()
+			svc, err := drive.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = DeleteDriveFile(svc, "1AbCdEfGhIjKlMnOpQrStUvWxYz")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DeleteDriveFile error = %v, want error: %v", err, tt.wantErr)
+			}
+			if requests != tt.dropped+1 {
+				t.Errorf("server saw %d requests, want %d", requests, tt.dropped+1)
+			}
+		})
+	}
+}
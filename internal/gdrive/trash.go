@@ -0,0 +1,91 @@
+// File: penguindex-go/internal/gdrive/trash.go
+package gdrive
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// trashFields is the per-file field list requested by ListTrash.
+const trashFields = "nextPageToken, files(id, name, mimeType, size, trashedTime, explicitlyTrashed, driveId)"
+
+// TrashDriveFile moves a file (or folder, with its contents) to the trash.
+// Unlike DeleteDriveFile this can be undone with RestoreDriveFile.
+func TrashDriveFile(svc *drive.Service, fileID string) (*drive.File, error) {
+	file, err := retryCall("trashing of "+fileID, func() (*drive.File, error) {
+		return svc.Files.Update(fileID, &drive.File{Trashed: true}).
+			SupportsAllDrives(true).
+			Fields("id", "name", "trashed").
+			Do()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to move file '%s' to trash: %w", fileID, err)
+	}
+	return file, nil
+}
+
+// RestoreDriveFile takes a file out of the trash, back into its original folder.
+func RestoreDriveFile(svc *drive.Service, fileID string) (*drive.File, error) {
+	// Trashed is a bool, so false must be sent explicitly.
+	file, err := retryCall("restore of "+fileID, func() (*drive.File, error) {
+		return svc.Files.Update(fileID, &drive.File{Trashed: false, ForceSendFields: []string{"Trashed"}}).
+			SupportsAllDrives(true).
+			Fields("id", "name", "trashed", "parents").
+			Do()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore file '%s' from trash: %w", fileID, err)
+	}
+	return file, nil
+}
+
+// ListTrash returns every explicitly trashed item in the service account's
+// own trash, or with driveID in that Shared Drive's trash. Children of a
+// trashed folder are not listed separately.
+func ListTrash(svc *drive.Service, driveID string) ([]*drive.File, error) {
+	var files []*drive.File
+	err := DefaultRetryPolicy.Do("trash listing", nil, func() error {
+		files = nil
+		call := svc.Files.List().SupportsAllDrives(true)
+		if driveID != "" {
+			// Shared Drive items have no owners, so 'me' in owners would hide them.
+			call = call.IncludeItemsFromAllDrives(true).Corpora("drive").DriveId(driveID).Q("trashed = true")
+		} else {
+			call = call.Q("trashed = true and 'me' in owners")
+		}
+		return call.
+			PageSize(1000).
+			Fields(trashFields).
+			Pages(context.Background(), func(page *drive.FileList) error {
+				for _, file := range page.Files {
+					if file.ExplicitlyTrashed {
+						files = append(files, file)
+					}
+				}
+				return nil
+			})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+	return files, nil
+}
+
+// EmptyTrash permanently deletes everything in the service account's trash,
+// or with driveID in that Shared Drive's trash (which needs the organizer
+// role).
+func EmptyTrash(svc *drive.Service, driveID string) error {
+	err := DefaultRetryPolicy.Do("emptying of trash", nil, func() error {
+		call := svc.Files.EmptyTrash()
+		if driveID != "" {
+			call = call.DriveId(driveID)
+		}
+		return call.Do()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to empty trash: %w", err)
+	}
+	return nil
+}
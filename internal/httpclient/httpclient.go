@@ -0,0 +1,210 @@
+// File: penguindex-go/internal/httpclient/httpclient.go
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults for Options fields left zero.
+const (
+	DEFAULT_CONNECT_TIMEOUT = 10 * time.Second
+	DEFAULT_READ_TIMEOUT    = 60 * time.Second
+)
+
+// Options configure the shared client used for the remote config documents,
+// Telegram and Google Drive.
+type Options struct {
+	ConnectTimeout time.Duration // TCP connect and TLS handshake
+	// ReadTimeout is how long a request may go without progress: no request
+	// body sent, no response headers or body received. Long transfers are
+	// fine as long as data keeps moving.
+	ReadTimeout time.Duration
+	Proxy       string // Proxy URL; empty uses HTTPS_PROXY/HTTP_PROXY/NO_PROXY
+	CAFile      string // PEM certificates trusted in addition to the system roots
+}
+
+var (
+	mu     sync.Mutex
+	shared *http.Client
+)
+
+// Configure builds the shared client from opts. Call it once at startup,
+// before anything uses Client.
+func Configure(opts Options) error {
+	client, err := New(opts)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	shared = client
+	mu.Unlock()
+	return nil
+}
+
+// Client returns the shared client, built with default Options if
+// Configure was not called.
+func Client() *http.Client {
+	mu.Lock()
+	defer mu.Unlock()
+	if shared == nil {
+		shared, _ = New(Options{}) // Cannot fail without a proxy or CA file
+	}
+	return shared
+}
+
+// New builds a client from opts. The client has no overall timeout, since
+// uploads and downloads may legitimately take hours; stalls are caught by
+// ReadTimeout instead.
+func New(opts Options) (*http.Client, error) {
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = DEFAULT_CONNECT_TIMEOUT
+	}
+	if opts.ReadTimeout <= 0 {
+		opts.ReadTimeout = DEFAULT_READ_TIMEOUT
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: opts.ConnectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = opts.ConnectTimeout
+	transport.ResponseHeaderTimeout = opts.ReadTimeout
+
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", opts.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s contains no PEM certificates", opts.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	return &http.Client{Transport: &idleTimeoutTransport{base: transport, timeout: opts.ReadTimeout}}, nil
+}
+
+// ErrTooLarge is returned by ReadLimited for oversized bodies.
+var ErrTooLarge = errors.New("response body too large")
+
+// ReadLimited reads r to the end, failing with ErrTooLarge if it holds more
+// than limit bytes.
+func ReadLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w (more than %d bytes)", ErrTooLarge, limit)
+	}
+	return data, nil
+}
+
+// IdleTimeoutError is returned when a request is cancelled for making no
+// progress. It is a net.Error with Timeout() true, so retry logic treats it
+// like any other network timeout.
+type IdleTimeoutError struct {
+	Idle time.Duration
+}
+
+func (e *IdleTimeoutError) Error() string {
+	return fmt.Sprintf("no data sent or received for %s", e.Idle)
+}
+func (e *IdleTimeoutError) Timeout() bool   { return true }
+func (e *IdleTimeoutError) Temporary() bool { return true }
+
+// idleTimeoutTransport cancels a request once neither its body nor the
+// response body has moved for timeout.
+type idleTimeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *idleTimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	watchdog := &watchdog{timeout: t.timeout}
+	watchdog.timer = time.AfterFunc(t.timeout, func() {
+		watchdog.fired.Store(true)
+		cancel()
+	})
+	req = req.WithContext(ctx)
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body = &progressBody{ReadCloser: req.Body, watchdog: watchdog}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		watchdog.timer.Stop()
+		cancel()
+		return nil, watchdog.wrap(err)
+	}
+	watchdog.timer.Reset(t.timeout)
+	resp.Body = &progressBody{ReadCloser: resp.Body, watchdog: watchdog, cancel: cancel}
+	return resp, nil
+}
+
+// watchdog is the idle timer of one request.
+type watchdog struct {
+	timer   *time.Timer
+	timeout time.Duration
+	fired   atomic.Bool
+}
+
+// wrap replaces the cancellation error caused by the timer with an
+// IdleTimeoutError.
+func (w *watchdog) wrap(err error) error {
+	if err != nil && w.fired.Load() {
+		return &IdleTimeoutError{Idle: w.timeout}
+	}
+	return err
+}
+
+// progressBody restarts the idle timer on every successful read. The
+// response body also releases the request context when closed.
+type progressBody struct {
+	io.ReadCloser
+	watchdog *watchdog
+	cancel   context.CancelFunc
+}
+
+func (b *progressBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.watchdog.timer.Reset(b.watchdog.timeout)
+	}
+	if err == io.EOF {
+		return n, err
+	}
+	return n, b.watchdog.wrap(err)
+}
+
+func (b *progressBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.cancel != nil {
+		b.watchdog.timer.Stop()
+		b.cancel()
+	}
+	return err
+}
@@ -8,6 +8,9 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"unicode/utf16"
+
+	"github.com/jendermine/penguindex-go/internal/httpclient"
 )
 
 // TelegramSendMessagePayload defines the structure for the message payload.
@@ -43,18 +46,20 @@ func escapeMarkdownV2(text string) string {
 }
 
 // SendNotification sends a message to a Telegram chat.
-func SendNotification(botToken, chatID, fileName, folderName, size, mimeType, createdTime, gdriveLink, ddlLink string) error {
+func SendNotification(botToken, chatID, fileName, folderName, size, mimeType, md5, createdTime, gdriveLink, ddlLink string) error {
 	messageText := fmt.Sprintf(
 		"*File Uploaded* ✅\n\n"+
 			"*File Name*: `%s`\n"+
 			"*Folder*: `%s`\n"+
 			"*Size*: `%s`\n"+
 			"*Type*: `%s`\n"+
+			"*MD5*: `%s`\n"+
 			"*Created*: `%s`",
 		escapeMarkdownV2(fileName),
 		escapeMarkdownV2(folderName),
 		escapeMarkdownV2(size),
 		escapeMarkdownV2(mimeType),
+		escapeMarkdownV2(md5),
 		escapeMarkdownV2(createdTime),
 	)
 
@@ -73,6 +78,175 @@ func SendNotification(botToken, chatID, fileName, folderName, size, mimeType, cr
 		},
 	}
 
+	return sendMessage(botToken, payload)
+}
+
+// BatchEntry describes one file in a multi-file upload notification.
+type BatchEntry struct {
+	Name    string
+	Size    string
+	MD5     string // Verified checksum; empty for failed or skipped entries
+	Failed  bool
+	Skipped bool // Already present in Drive, not uploaded
+}
+
+// maxMessageLength is Telegram's limit for a message, in UTF-16 code units.
+// Lengths here are measured before MarkdownV2 escapes are removed, so a
+// message within it is always accepted.
+const maxMessageLength = 4096
+
+// messageLength returns the length of text as Telegram counts it.
+func messageLength(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
+// fitLines joins lines, each ending in a newline, into at most budget
+// characters. Once the next line would not fit, the remaining ones are
+// replaced by more(n), for which room is always kept.
+func fitLines(lines []string, budget int, more func(n int) string) string {
+	reserve := messageLength(more(len(lines)))
+	var list strings.Builder
+	used := 0
+	for i, line := range lines {
+		need := messageLength(line)
+		if i < len(lines)-1 {
+			need += reserve
+		}
+		if used+need > budget {
+			list.WriteString(more(len(lines) - i))
+			break
+		}
+		list.WriteString(line)
+		used += messageLength(line)
+	}
+	return list.String()
+}
+
+// SendBatchNotification sends a single aggregated message for a multi-file upload.
+func SendBatchNotification(botToken, chatID, title, folderName, totalSize, folderLink string, entries []BatchEntry) error {
+	messageText := batchMessageText(title, folderName, totalSize, entries)
+
+	payload := TelegramSendMessagePayload{
+		ChatID:                chatID,
+		Text:                  messageText,
+		ParseMode:             "MarkdownV2",
+		DisableWebPagePreview: true,
+	}
+	if folderLink != "" {
+		payload.ReplyMarkup = &InlineKeyboardMarkup{
+			InlineKeyboard: [][]InlineKeyboardButton{
+				{
+					{Text: "📁 Open Folder", URL: folderLink},
+				},
+			},
+		}
+	}
+
+	return sendMessage(botToken, payload)
+}
+
+// batchMessageText builds the text of a batch notification, listing as many
+// entries as fit in one message.
+func batchMessageText(title, folderName, totalSize string, entries []BatchEntry) string {
+	var uploaded, skipped, failed int
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		mark := "✅"
+		switch {
+		case entry.Failed:
+			failed++
+			mark = "❌"
+		case entry.Skipped:
+			skipped++
+			mark = "⏭"
+		default:
+			uploaded++
+		}
+		line := fmt.Sprintf("%s `%s` \\(%s\\)\n", mark, escapeMarkdownV2(entry.Name), escapeMarkdownV2(entry.Size))
+		if entry.MD5 != "" {
+			line += fmt.Sprintf("      md5 `%s`\n", escapeMarkdownV2(entry.MD5))
+		}
+		lines = append(lines, line)
+	}
+
+	header := fmt.Sprintf(
+		"*%s* %s\n\n"+
+			"*Folder*: `%s`\n"+
+			"*Files*: `%d uploaded, %d skipped, %d failed`\n"+
+			"*Total Size*: `%s`\n\n",
+		escapeMarkdownV2(title),
+		statusMark(failed),
+		escapeMarkdownV2(folderName),
+		uploaded,
+		skipped,
+		failed,
+		escapeMarkdownV2(totalSize),
+	)
+	return header + fitLines(lines, maxMessageLength-messageLength(header), func(n int) string {
+		return fmt.Sprintf("_\\.\\.\\. and %d more_\n", n)
+	})
+}
+
+// PruneFolderSummary describes what a prune run removed from one folder.
+type PruneFolderSummary struct {
+	Folder  string
+	Removed int
+	Failed  int
+	Size    string // Total size of the removed files
+}
+
+// SendPruneNotification reports a prune run. action is "trashed" or "deleted".
+func SendPruneNotification(botToken, chatID, action, reclaimed string, folders []PruneFolderSummary) error {
+	return sendMessage(botToken, TelegramSendMessagePayload{
+		ChatID:                chatID,
+		Text:                  pruneMessageText(action, reclaimed, folders),
+		ParseMode:             "MarkdownV2",
+		DisableWebPagePreview: true,
+	})
+}
+
+// pruneMessageText builds the text of a prune notification, listing as many
+// folders as fit in one message.
+func pruneMessageText(action, reclaimed string, folders []PruneFolderSummary) string {
+	var removed, failed int
+	lines := make([]string, 0, len(folders))
+	for _, folder := range folders {
+		removed += folder.Removed
+		failed += folder.Failed
+		line := fmt.Sprintf("📁 `%s`: %d %s \\(%s\\)", escapeMarkdownV2(folder.Folder), folder.Removed, escapeMarkdownV2(action), escapeMarkdownV2(folder.Size))
+		if folder.Failed > 0 {
+			line += fmt.Sprintf(", %d failed ❌", folder.Failed)
+		}
+		lines = append(lines, line+"\n")
+	}
+
+	header := fmt.Sprintf(
+		"*Retention Prune* %s\n\n"+
+			"*Files*: `%d %s, %d failed`\n"+
+			"*Reclaimed*: `%s`\n\n",
+		statusMark(failed),
+		removed,
+		escapeMarkdownV2(action),
+		failed,
+		escapeMarkdownV2(reclaimed),
+	)
+	return header + fitLines(lines, maxMessageLength-messageLength(header), func(n int) string {
+		return fmt.Sprintf("_\\.\\.\\. and %d more folders_\n", n)
+	})
+}
+
+func statusMark(failed int) string {
+	if failed > 0 {
+		return "⚠️"
+	}
+	return "✅"
+}
+
+// maxErrorBody caps how much of an error response is read into the message.
+const maxErrorBody = 4096
+
+// sendMessage posts a prepared payload to the Telegram Bot API.
+func sendMessage(botToken string, payload TelegramSendMessagePayload) error {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal Telegram payload: %w", err)
@@ -80,14 +254,14 @@ func SendNotification(botToken, chatID, fileName, folderName, size, mimeType, cr
 
 	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", botToken)
 
-	resp, err := http.Post(apiURL, "application/json", bytes.NewBuffer(payloadBytes))
+	resp, err := httpclient.Client().Post(apiURL, "application/json", bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		return fmt.Errorf("failed to send Telegram message request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
 		return fmt.Errorf("Telegram API error: %s - %s", resp.Status, string(bodyBytes))
 	}
 
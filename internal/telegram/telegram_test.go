@@ -0,0 +1,99 @@
+// File: penguindex-go/internal/telegram/telegram_test.go
+package telegram
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var moreLine = regexp.MustCompile(`and (\d+) more`)
+
+// listedAndOmitted counts the entry lines starting with mark and the number
+// given in the "... and N more" line, if any.
+func listedAndOmitted(t *testing.T, text, mark string) (listed, omitted int) {
+	t.Helper()
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, mark) {
+			listed++
+		}
+	}
+	if match := moreLine.FindStringSubmatch(text); match != nil {
+		var err error
+		if omitted, err = strconv.Atoi(match[1]); err != nil {
+			t.Fatalf("bad count in %q: %v", match[0], err)
+		}
+	}
+	return listed, omitted
+}
+
+func TestBatchMessageTextFitsLimit(t *testing.T) {
+	longPath := strings.Repeat("season.01/episode-name_with.many-escapes/", 6)
+	tests := []struct {
+		name        string
+		count       int
+		path        string
+		wantOmitted bool
+	}{
+		{name: "few short entries", count: 3, path: "file.txt"},
+		{name: "many short entries", count: 200, path: "f.txt", wantOmitted: true},
+		{name: "twenty long paths", count: 20, path: longPath, wantOmitted: true},
+		{name: "one entry longer than a message", count: 1, path: strings.Repeat("x", 5000), wantOmitted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := make([]BatchEntry, tt.count)
+			for i := range entries {
+				entries[i] = BatchEntry{Name: fmt.Sprintf("%s%03d", tt.path, i), Size: "1.0 GB", MD5: strings.Repeat("0f", 16)}
+			}
+			text := batchMessageText("Directory Upload", "Backups", "200 GB", entries)
+			if n := messageLength(text); n > maxMessageLength {
+				t.Fatalf("message is %d characters, more than %d", n, maxMessageLength)
+			}
+			listed, omitted := listedAndOmitted(t, text, "✅")
+			if listed+omitted != tt.count {
+				t.Errorf("listed %d and omitted %d entries, want %d in total", listed, omitted, tt.count)
+			}
+			if (omitted > 0) != tt.wantOmitted {
+				t.Errorf("omitted %d entries, want omitted: %v", omitted, tt.wantOmitted)
+			}
+		})
+	}
+}
+
+func TestFitLines(t *testing.T) {
+	more := func(n int) string { return fmt.Sprintf("+%d\n", n) }
+	lines := []string{"aaaa\n", "bbbb\n", "cccc\n"}
+	tests := []struct {
+		budget int
+		want   string
+	}{
+		{budget: 15, want: "aaaa\nbbbb\ncccc\n"},
+		{budget: 14, want: "aaaa\nbbbb\n+1\n"},
+		{budget: 12, want: "aaaa\n+2\n"},
+		{budget: 8, want: "aaaa\n+2\n"},
+		{budget: 7, want: "+3\n"},
+	}
+	for _, tt := range tests {
+		if got := fitLines(lines, tt.budget, more); got != tt.want {
+			t.Errorf("fitLines(budget %d) = %q, want %q", tt.budget, got, tt.want)
+		}
+	}
+}
+
+func TestPruneMessageTextFitsLimit(t *testing.T) {
+	folders := make([]PruneFolderSummary, 300)
+	for i := range folders {
+		folders[i] = PruneFolderSummary{Folder: fmt.Sprintf("Backups/host-%03d.example.com/daily", i), Removed: 3, Failed: 1, Size: "12.5 GB"}
+	}
+	text := pruneMessageText("trashed", "3.7 TB", folders)
+	if n := messageLength(text); n > maxMessageLength {
+		t.Fatalf("message is %d characters, more than %d", n, maxMessageLength)
+	}
+	listed, omitted := listedAndOmitted(t, text, "📁")
+	if omitted == 0 || listed+omitted != len(folders) {
+		t.Errorf("listed %d and omitted %d folders, want some omitted and %d in total", listed, omitted, len(folders))
+	}
+}
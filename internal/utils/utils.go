@@ -1,7 +1,44 @@
 // File: penguindex-go/internal/utils/utils.go
 package utils
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// APP_DIR_NAME is the directory name used under the user's cache and config directories.
+const APP_DIR_NAME = "penguindex"
+
+// Quiet drops progress and success messages printed through Status and hides
+// progress bars. main sets it from -quiet and -json; results go straight to
+// stdout and warnings through Warn, so neither is affected.
+var Quiet bool
+
+// Status prints a progress or success line on stdout unless Quiet is set.
+func Status(line string) {
+	if !Quiet {
+		fmt.Println(line)
+	}
+}
+
+// Warn prints a warning line on stderr, so it is seen even with -quiet and
+// never mixes with -json output.
+func Warn(line string) {
+	fmt.Fprintln(os.Stderr, line)
+}
+
+// ProgressWriter returns where progress bars are drawn: stdout, or nowhere if Quiet is set.
+func ProgressWriter() io.Writer {
+	if Quiet {
+		return io.Discard
+	}
+	return os.Stdout
+}
 
 // HumanReadableSize converts bytes to a human-readable string (e.g., KiB, MiB).
 func HumanReadableSize(bytes uint64) string {
@@ -16,3 +53,75 @@ func HumanReadableSize(bytes uint64) string {
 	}
 	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
+
+// ParseSize parses a size such as "1500", "512 MiB", "50GB" or "2T" into
+// bytes. Like HumanReadableSize, all units are binary (K = 1024), whether or
+// not they are written with an "i".
+func ParseSize(value string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(value))
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "B"), "I")
+	multiplier := int64(1)
+	if n := len(text); n > 0 {
+		if exp := strings.IndexByte("KMGTPE", text[n-1]); exp >= 0 {
+			multiplier = int64(1) << (10 * (exp + 1))
+			text = strings.TrimSpace(text[:n-1])
+		}
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q (want e.g. 500MiB, 20 GB or 1T)", value)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// AppCacheDir returns the per-user directory for local state such as resumable
+// upload sessions, creating it with user-only permissions if needed.
+func AppCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user cache directory: %w", err)
+	}
+	dir := filepath.Join(base, APP_DIR_NAME)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// ReadJSONFile decodes the JSON file at path into v. A missing file is reported
+// as an error satisfying errors.Is(err, fs.ErrNotExist).
+func ReadJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// WriteJSONFile atomically replaces the file at path with the JSON encoding of v.
+// The file is written with user-only permissions.
+func WriteJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
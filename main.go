@@ -4,151 +4,329 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
 
+	"github.com/jendermine/penguindex-go/internal/agent"
 	"github.com/jendermine/penguindex-go/internal/auth"
-	"github.com/jendermine/penguindex-go/internal/commands"
+	"github.com/jendermine/penguindex-go/internal/cli"
 	"github.com/jendermine/penguindex-go/internal/config"
+	"github.com/jendermine/penguindex-go/internal/httpclient"
+	"github.com/jendermine/penguindex-go/internal/utils"
+	"google.golang.org/api/drive/v3"
 	"github.com/fatih/color" // For colored output
-	"golang.org/x/term"      // For PIN input
 )
 
-// !!! REPLACE THESE WITH YOUR ACTUAL VALUES !!!
-const EMBEDDED_BUNDLE_URL = "https://gist.githubusercontent.com/jendermine/f963de2bcf12c37421277d7702466b2b/raw/ceabd48a9f0f6412a1dd42af44f20b5619d04d6d/log.json"
-const TELEGRAM_CHAT_ID_URL = "https://gist.githubusercontent.com/jendermine/66015cce5cf15c0e04ba5987cb3ca342/raw/2e0f17aaee25abbcfa8a254f390bcb214775826b/log2.json"
+// Color setup (optional)
+var (
+	errorColor   = color.New(color.FgRed).SprintfFunc()
+	successColor = color.New(color.FgGreen).SprintfFunc()
+	infoColor    = color.New(color.FgYellow).SprintfFunc()
+)
+
+// globalOptions are the flags every command accepts, before or after its name.
+type globalOptions struct {
+	configPath    string
+	profile       string
+	pinFile       string
+	pinStdin      bool
+	refreshConfig bool
+	json          bool
+	quiet         bool
+	verbose       bool
+	noColor       bool
+}
+
+func (o *globalOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.configPath, "config", "", "Path to the config file (default: <user config dir>/penguindex/config.json, or $PENGUINDEX_CONFIG)")
+	fs.StringVar(&o.profile, "profile", "", "Config profile to use (default: the file's default_profile, or $PENGUINDEX_PROFILE)")
+	fs.StringVar(&o.pinFile, "pin-file", "", "Read the bundle PIN from the first line of this file (must not be readable by other users)")
+	fs.BoolVar(&o.pinStdin, "pin-stdin", false, "Read the bundle PIN from the first line of stdin")
+	fs.BoolVar(&o.refreshConfig, "refresh-config", false, "Fetch the bundle and chat ID from their URLs, bypassing the offline cache")
+	fs.BoolVar(&o.json, "json", false, "Print the result as JSON (ls, drives, accounts, trash ls, agent status, config show)")
+	fs.BoolVar(&o.quiet, "quiet", false, "Only print results, warnings and errors")
+	fs.BoolVar(&o.verbose, "verbose", false, "Also print which settings, trust anchors and network options are used")
+	fs.BoolVar(&o.noColor, "no-color", false, "Disable colored output (also set by $NO_COLOR)")
+}
+
+// detail prints a -verbose message. It goes to stderr so it never mixes with
+// -json output.
+func (o *globalOptions) detail(format string, a ...any) {
+	if o.verbose {
+		fmt.Fprintln(os.Stderr, color.HiBlackString(format, a...))
+	}
+}
+
+// warn prints a warning on stderr; -quiet does not hide warnings.
+func warn(msg string) {
+	utils.Warn(color.YellowString(msg))
+}
 
 func main() {
-	if len(os.Args) < 2 {
-		printUsage()
-		os.Exit(1)
+	opts := &globalOptions{}
+	globals := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	opts.register(globals)
+
+	// Nothing is loaded, fetched, decrypted or authenticated up front. Each
+	// command parses and checks its arguments first and then asks in for what
+	// it needs, so help, completion, usage errors and local commands work
+	// offline and without a PIN.
+	in := &initializer{opts: opts}
+	app := &cli.App{
+		Name:     filepath.Base(os.Args[0]),
+		Summary:  "Upload to and manage Google Drive with credentials from an encrypted, PIN-protected bundle.",
+		Globals:  globals,
+		Commands: commandTree(in),
+		GlobalFlagValues: map[string]func() []string{
+			"profile": in.profileNames,
+		},
+		Before: func(cmd *cli.Command) error {
+			if opts.noColor {
+				color.NoColor = true
+			}
+			if opts.quiet && opts.verbose {
+				return fmt.Errorf("-quiet and -verbose cannot be used together")
+			}
+			// Handlers print progress through utils.Status, which both flags silence.
+			utils.Quiet = opts.quiet || opts.json
+			if opts.json && !cmd.JSON {
+				return fmt.Errorf("-json is not supported by '%s'", cmd.Path())
+			}
+			return nil
+		},
+		ErrorColor: errorColor,
 	}
+	os.Exit(app.Run(os.Args[1:]))
+}
+
+// requirement is something a command needs set up before it runs.
+type requirement int
+
+const (
+	needsDrive    requirement = 1 << iota // The decrypted bundle and an authenticated Drive service
+	needsTelegram                         // The decrypted bundle's bot token and the chat ID
+)
+
+// runtimeDeps is what initialize set up.
+type runtimeDeps struct {
+	driveService    *drive.Service
+	driveHTTPClient *http.Client
+}
 
-	// Color setup (optional)
-	errorColor := color.New(color.FgRed).SprintfFunc()
-	successColor := color.New(color.FgGreen).SprintfFunc()
-	infoColor := color.New(color.FgYellow).SprintfFunc()
+// initializer sets up command requirements on first use. Errors are fatal,
+// like everywhere else in main.
+type initializer struct {
+	opts *globalOptions
 
-	fmt.Println(infoColor("Fetching configuration..."))
-	appConfigDetails, err := config.FetchRemoteConfigDetails(EMBEDDED_BUNDLE_URL, TELEGRAM_CHAT_ID_URL)
+	settings *config.Settings  // Set by load
+	appCfg   *config.AppConfig // Local fields are set by load; initialize adds the secrets
+	verifier *config.Verifier
+}
+
+// load resolves the local settings from the config file and environment.
+func (in *initializer) load() *config.Settings {
+	if in.settings != nil {
+		return in.settings
+	}
+	settings, err := config.LoadSettings(in.opts.configPath, in.opts.profile)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, errorColor("Error fetching remote configuration: %v", err))
+		fmt.Fprintln(os.Stderr, errorColor("Error loading configuration: %v", err))
 		os.Exit(1)
 	}
+	in.settings = settings
+	in.appCfg = &config.AppConfig{
+		DefaultFolderID: settings.DefaultFolderID.Value,
+		DDLBaseURL:      settings.DDLBaseURL.Value,
+		FolderAliases:   settings.FolderAliases(),
+		Retention:       settings.RetentionRules(),
+	}
+	in.opts.detail("Config file %s, profile %s (from %s)", settings.ConfigPath, settings.Profile.Value, settings.Profile.Source)
+	return settings
+}
+
+// app returns the local part of the application config.
+func (in *initializer) app() *config.AppConfig {
+	in.load()
+	return in.appCfg
+}
 
-	fmt.Print("Enter PIN: ")
-	pinBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+func (in *initializer) pinOptions() config.PINOptions {
+	return config.PINOptions{File: in.opts.pinFile, Stdin: in.opts.pinStdin}
+}
+
+// folderAliases lists the configured aliases for shell completion, which
+// must neither fail nor print anything else.
+func (in *initializer) folderAliases() []string {
+	settings, err := config.LoadSettings(in.opts.configPath, in.opts.profile)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, errorColor("Error reading PIN: %v", err))
-		os.Exit(1)
+		return nil
 	}
-	pin := string(pinBytes)
-	fmt.Println() // Newline after PIN input
+	var names []string
+	for alias := range settings.FolderAliases() {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	return names
+}
 
-	decryptedBundle, err := config.DecryptBundle(appConfigDetails.EncryptedBundleHex, pin)
+// profileNames lists the profiles in the config file for shell completion.
+func (in *initializer) profileNames() []string {
+	settings, err := config.LoadSettings(in.opts.configPath, "")
 	if err != nil {
-		fmt.Fprintln(os.Stderr, errorColor("Error decrypting bundle (check PIN or bundle URL): %v", err))
-		os.Exit(1)
+		return nil
 	}
-	fmt.Println(successColor("Bundle decrypted successfully."))
-
-	appCfg := &config.AppConfig{
-		ServiceAccountJSON: decryptedBundle.ServiceAccountJSONString,
-		TelegramBotToken:   decryptedBundle.TelegramBotToken,
-		TelegramChatID:     appConfigDetails.TelegramChatID,
-		DefaultFolderID:    config.DEFAULT_TEST_FOLDER_ID, // From config package
+	var names []string
+	for name := range settings.File.Profiles {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	fmt.Println(infoColor("Authenticating with Google Drive..."))
-	driveHTTPClient, err := auth.GetAuthenticatedClient(appCfg.ServiceAccountJSON)
+// network configures the shared HTTP client and returns the verifier for the
+// remote documents.
+func (in *initializer) network() *config.Verifier {
+	if in.verifier != nil {
+		return in.verifier
+	}
+	settings := in.load()
+	httpOpts, err := settings.HTTPOptions()
+	if err == nil {
+		err = httpclient.Configure(httpOpts)
+	}
 	if err != nil {
-		fmt.Fprintln(os.Stderr, errorColor("Google Drive authentication failed: %v", err))
+		fmt.Fprintln(os.Stderr, errorColor("Error in network configuration: %v", err))
 		os.Exit(1)
 	}
+	proxy := httpOpts.Proxy
+	if proxy == "" {
+		proxy = "(from environment)"
+	}
+	in.opts.detail("Network: proxy %s, CA file %s, connect timeout %s, read timeout %s", proxy, orNone(httpOpts.CAFile), httpOpts.ConnectTimeout, httpOpts.ReadTimeout)
 
-	driveService, err := auth.NewDriveService(driveHTTPClient)
+	in.verifier, err = config.NewVerifier(settings)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, errorColor("Failed to create Google Drive service: %v", err))
+		fmt.Fprintln(os.Stderr, errorColor("Error in signing configuration: %v", err))
 		os.Exit(1)
 	}
-	// Perform an auth check
-	gDriveUser, err := driveService.About.Get().Fields("user").Do()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, errorColor("Failed to verify Drive service authentication: %v", err))
-		os.Exit(1)
+	switch {
+	case !in.verifier.Enabled():
+	case in.verifier.PublicKey != nil:
+		in.opts.detail("Verifying the bundle and chat ID with the signing key from %s", settings.SigningPublicKey.Source)
+	default:
+		in.opts.detail("Verifying the bundle and chat ID against the digests from %s", settings.BundleSHA256.Source)
 	}
-	fmt.Println(successColor("Successfully authenticated with Google Drive as: %s", gDriveUser.User.EmailAddress))
+	return in.verifier
+}
 
+// initialize fetches the remote config, decrypts the bundle and
+// authenticates with Drive as far as needs requires. Commands call it once.
+func (in *initializer) initialize(needs requirement) *runtimeDeps {
+	verifier := in.network()
+	settings := in.settings
+	opts := in.opts
 
-	command := os.Args[1]
-	args := os.Args[2:]
+	maxStaleness, err := settings.MaxStaleness()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, errorColor("Error loading configuration: %v", err))
+		os.Exit(1)
+	}
+	remoteCache := &config.RemoteCache{
+		MaxStaleness: maxStaleness,
+		Refresh:      opts.refreshConfig,
+		Logf:         warn,
+	}
 
-	switch command {
-	case "upload":
-		uploadCmd := flag.NewFlagSet("upload", flag.ExitOnError)
-		filePath := uploadCmd.String("file", "", "Path to the file to upload (required)")
-		folderID := uploadCmd.String("folder", "", "Google Drive folder ID (optional, uses default if not provided)")
+	utils.Status(infoColor("Fetching configuration..."))
+	if !verifier.Enabled() {
+		warn("Warning: no signing_public_key or pinned digests are configured; the bundle and chat ID are not verified.")
+	}
+	// Both documents are fetched concurrently and verified before the PIN
+	// prompt. The bundle is skipped if the agent already holds it, and the
+	// chat ID if the command sends no notifications.
+	decryptedBundle := agent.Lookup(settings.BundleURL.Value)
+	bundleURL := settings.BundleURL.Value
+	if decryptedBundle != nil {
+		bundleURL = ""
+	}
+	chatIDURL := ""
+	if needs&needsTelegram != 0 {
+		chatIDURL = settings.ChatIDURL.Value
+	}
+	opts.detail("Bundle URL %s, chat ID URL %s", orNone(bundleURL), orNone(chatIDURL))
+	remote, err := config.FetchRemoteConfig(bundleURL, chatIDURL, verifier, remoteCache)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, errorColor("Error fetching remote configuration: %v", err))
+		os.Exit(1)
+	}
 
-		uploadCmd.Usage = func() {
-			fmt.Fprintf(os.Stderr, "Usage: %s upload -file <filepath> [-folder <folderID>]\n", os.Args[0])
-			uploadCmd.PrintDefaults()
-		}
-		if err := uploadCmd.Parse(args); err != nil {
-            fmt.Fprintln(os.Stderr, errorColor("Error parsing upload flags: %v", err))
+	if decryptedBundle != nil {
+		utils.Status(successColor("Using decrypted bundle from agent."))
+	} else {
+		pin, pinSource, err := config.ReadPIN(in.pinOptions())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, errorColor("Error reading PIN: %v", err))
 			os.Exit(1)
 		}
 
-
-		if *filePath == "" {
-			fmt.Fprintln(os.Stderr, errorColor("Error: --file flag is required for upload."))
-			uploadCmd.Usage()
-			os.Exit(1)
-		}
-		actualFolderID := *folderID
-		if actualFolderID == "" {
-			actualFolderID = appCfg.DefaultFolderID
-		}
-		err := commands.HandleUpload(driveService, appCfg, *filePath, actualFolderID)
+		decryptedBundle, err = config.DecryptBundle(remote.EncryptedBundle, pin)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, errorColor("Upload command failed: %v", err))
+			fmt.Fprintln(os.Stderr, errorColor("Error decrypting bundle (check PIN from %s or bundle URL): %v", pinSource, err))
 			os.Exit(1)
 		}
-		fmt.Println(successColor("Upload command completed successfully."))
-
-	case "delete":
-		deleteCmd := flag.NewFlagSet("delete", flag.ExitOnError)
-		fileIDOrLink := deleteCmd.String("id", "", "File ID or Google Drive link to delete (required)")
-		
-		deleteCmd.Usage = func() {
-			fmt.Fprintf(os.Stderr, "Usage: %s delete -id <fileID_or_link>\n", os.Args[0])
-			deleteCmd.PrintDefaults()
+		utils.Status(successColor("Bundle decrypted successfully."))
+		if header, err := config.InspectBundle(remote.EncryptedBundle); err == nil {
+			opts.detail("Bundle version %d, PIN from %s", header.Version, pinSource)
+			if header.Version == config.BUNDLE_VERSION_LEGACY {
+				warn(fmt.Sprintf("Warning: this is a legacy (version 0) bundle with PBKDF2 and no authenticated header. Upgrade it with '%s bundle rekey -o <file>' and publish the result.", os.Args[0]))
+			}
 		}
-		if err := deleteCmd.Parse(args); err != nil {
-            fmt.Fprintln(os.Stderr, errorColor("Error parsing delete flags: %v", err))
-			os.Exit(1)
+		if err := agent.Add(settings.BundleURL.Value, decryptedBundle); err == nil {
+			utils.Status(infoColor("Bundle cached in the running agent."))
 		}
+	}
 
+	appCfg := in.appCfg
+	appCfg.ServiceAccountJSONs = decryptedBundle.ServiceAccounts()
+	if needs&needsTelegram != 0 {
+		appCfg.TelegramBotToken = decryptedBundle.TelegramBotToken
+		appCfg.TelegramChatID = remote.TelegramChatID
+	}
 
-		if *fileIDOrLink == "" {
-			fmt.Fprintln(os.Stderr, errorColor("Error: --id flag is required for delete."))
-			deleteCmd.Usage()
-			os.Exit(1)
-		}
-		err := commands.HandleDelete(driveService, appCfg, *fileIDOrLink)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, errorColor("Delete command failed: %v", err))
-			os.Exit(1)
-		}
-		fmt.Println(successColor("Delete command completed successfully."))
+	if needs&needsDrive == 0 {
+		return &runtimeDeps{}
+	}
+	utils.Status(infoColor("Authenticating with Google Drive..."))
+	driveHTTPClient, err := auth.GetAuthenticatedClient(appCfg.ServiceAccountJSONs...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, errorColor("Google Drive authentication failed: %v", err))
+		os.Exit(1)
+	}
+	if len(appCfg.ServiceAccountJSONs) > 1 {
+		pool, _ := auth.PoolFromClient(driveHTTPClient)
+		utils.Status(infoColor("Using service account pool of %d accounts, starting with %s", len(appCfg.ServiceAccountJSONs), pool.CurrentAccount()))
+	}
 
-	default:
-		fmt.Fprintln(os.Stderr, errorColor("Unknown command: %s", command))
-		printUsage()
+	driveService, err := auth.NewDriveService(driveHTTPClient)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, errorColor("Failed to create Google Drive service: %v", err))
 		os.Exit(1)
 	}
+	// Perform an auth check
+	gDriveUser, err := driveService.About.Get().Fields("user").Do()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, errorColor("Failed to verify Drive service authentication: %v", err))
+		os.Exit(1)
+	}
+	utils.Status(successColor("Successfully authenticated with Google Drive as: %s", gDriveUser.User.EmailAddress))
+	return &runtimeDeps{driveService: driveService, driveHTTPClient: driveHTTPClient}
 }
 
-func printUsage() {
-	fmt.Fprintf(os.Stderr, "Usage: %s <command> [arguments]\n", os.Args[0])
-	fmt.Fprintln(os.Stderr, "Available commands: upload, delete")
-	fmt.Fprintln(os.Stderr, "Use <command> -help for more information on a specific command.")
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
 }